@@ -0,0 +1,50 @@
+// Node module hooks that let `node --test` run the TypeScript sources directly:
+// '@/...' imports resolve to src/ (as in tsconfig paths), and .ts files are transpiled on load.
+import { readFile, stat } from 'node:fs/promises';
+import { fileURLToPath } from 'node:url';
+import ts from 'typescript';
+
+const srcRoot = new URL('../src/', import.meta.url);
+
+async function isFile(url) {
+  try {
+    return (await stat(fileURLToPath(url))).isFile();
+  } catch {
+    return false;
+  }
+}
+
+export async function resolve(specifier, context, nextResolve) {
+  const isAlias = specifier.startsWith('@/');
+  const isRelative = specifier.startsWith('./') || specifier.startsWith('../');
+  const fromTs = context.parentURL?.endsWith('.ts');
+
+  if (isAlias || (isRelative && fromTs)) {
+    const base = isAlias ? new URL(specifier.slice(2), srcRoot) : new URL(specifier, context.parentURL);
+    for (const candidate of [base.href, `${base.href}.ts`, `${base.href}/index.ts`]) {
+      if (await isFile(new URL(candidate))) {
+        return { url: candidate, shortCircuit: true };
+      }
+    }
+  }
+
+  return nextResolve(specifier, context);
+}
+
+export async function load(url, context, nextLoad) {
+  if (!url.endsWith('.ts')) {
+    return nextLoad(url, context);
+  }
+
+  const source = await readFile(fileURLToPath(url), 'utf8');
+  const { outputText } = ts.transpileModule(source, {
+    fileName: fileURLToPath(url),
+    compilerOptions: {
+      module: ts.ModuleKind.ESNext,
+      target: ts.ScriptTarget.ES2022,
+      esModuleInterop: true
+    }
+  });
+
+  return { format: 'module', source: outputText, shortCircuit: true };
+}
@@ -11,9 +11,15 @@ import { Card, CardHeader, CardContent, CardTitle, CardDescription } from '@/com
 import Button from '@/components/ui/Button';
 import { Textarea } from '@/components/ui/Input';
 import { useGameAnalysis } from '@/hooks/useGameAnalysis';
-import { convertScoreToString, getScoreColor } from '@/utils/stockfish';
+import { convertScoreToString, formatWinPercentage, getScoreColor, CLASSIFICATION_DISPLAY } from '@/utils/stockfish';
 
 export default function Home() {
+  // Append ?debug to the URL to see why each move got its classification
+  const [debugMode, setDebugMode] = React.useState(false);
+  React.useEffect(() => {
+    setDebugMode(new URLSearchParams(window.location.search).has('debug'));
+  }, []);
+
   const {
     // Game state
     gameState,
@@ -36,6 +42,7 @@ export default function Home() {
     // Analysis
     gameAnalysis,
     isAnalyzingGame,
+    analysisError,
     analysisProgress,
     whiteAccuracy,
     blackAccuracy,
@@ -49,12 +56,13 @@ export default function Home() {
     // Actions
     loadGame,
     resetGame,
-    stopAnalysis
-  } = useGameAnalysis();
+    stopAnalysis,
+    getPrincipalVariation
+  } = useGameAnalysis({ debug: debugMode });
 
   const handleLoadPGN = async (pgn: string) => {
     if (!pgn.trim()) {
-      alert('Please paste a PGN game first');
+      alert('Please paste a PGN game or Lichess game URL first');
       return;
     }
     await loadGame(pgn);
@@ -76,21 +84,7 @@ export default function Home() {
   // Get current move analysis for display
   const currentMoveAnalysis = gameAnalysis?.moves[currentMoveIndex];
   const currentEval = currentPositionEvaluation || currentMoveAnalysis?.evaluation;
-
-  // Get move classification color
-  const getClassificationColor = (classification: string) => {
-    switch (classification) {
-      case 'brilliant': return 'text-cyan-600 bg-cyan-50';
-      case 'great': return 'text-blue-600 bg-blue-50';
-      case 'best': return 'text-green-600 bg-green-50';
-      case 'good': return 'text-green-700 bg-green-50';
-      case 'inaccuracy': return 'text-yellow-600 bg-yellow-50';
-      case 'mistake': return 'text-orange-600 bg-orange-50';
-      case 'blunder': return 'text-red-600 bg-red-50';
-      case 'miss': return 'text-red-700 bg-red-50';
-      default: return 'text-gray-600 bg-gray-50';
-    }
-  };
+  const engineLine = getPrincipalVariation(currentMoveIndex);
 
   return (
     <div className="min-h-screen bg-gray-50">
@@ -135,6 +129,15 @@ export default function Home() {
 
       {/* Main Content */}
       <main className="max-w-7xl mx-auto px-4 sm:px-6 lg:px-8 py-8">
+        {/* Analysis Error */}
+        {analysisError && !isAnalyzingGame && (
+          <Card className="mb-6">
+            <CardContent className="py-4">
+              <div className="text-sm text-red-600">{analysisError}</div>
+            </CardContent>
+          </Card>
+        )}
+
         {/* Analysis Progress */}
         {isAnalyzingGame && (
           <Card className="mb-6">
@@ -208,12 +211,52 @@ export default function Home() {
                           {currentMoveAnalysis.move}
                         </div>
                       </div>
-                      <div className={`px-3 py-1 rounded-full text-sm font-medium ${getClassificationColor(currentMoveAnalysis.classification)}`}>
-                        {currentMoveAnalysis.classification.charAt(0).toUpperCase() + currentMoveAnalysis.classification.slice(1)}
+                      <div className={`px-3 py-1 rounded-full text-sm font-medium ${CLASSIFICATION_DISPLAY[currentMoveAnalysis.classification].badgeColor}`}>
+                        {CLASSIFICATION_DISPLAY[currentMoveAnalysis.classification].symbol}{' '}
+                        {CLASSIFICATION_DISPLAY[currentMoveAnalysis.classification].label}
+                        {currentMoveAnalysis.provisional && ' (provisional)'}
                       </div>
                     </div>
-                    
-                    {currentMoveAnalysis.alternativeMoves && currentMoveAnalysis.alternativeMoves.length > 0 && (
+
+                    {currentMoveAnalysis.comment && (
+                      <div className="text-sm text-gray-700">
+                        {currentMoveAnalysis.comment}
+                      </div>
+                    )}
+
+                    {currentMoveAnalysis.suggestedMove && (
+                      <div>
+                        <div className="text-sm font-medium text-gray-700 mb-2">Better Was:</div>
+                        <div className="text-sm text-gray-900 font-semibold">
+                          {currentMoveAnalysis.suggestedMove.san}
+                          <span className="ml-2 font-normal text-green-600">
+                            +{formatWinPercentage(currentMoveAnalysis.suggestedMove.improvement)}
+                          </span>
+                        </div>
+                        {currentMoveAnalysis.suggestedMove.line.length > 1 && (
+                          <div className="text-sm text-gray-500">
+                            {currentMoveAnalysis.suggestedMove.line.join(' ')}
+                          </div>
+                        )}
+                      </div>
+                    )}
+
+                    {currentMoveAnalysis.trace && (
+                      <div className="border-t pt-3 text-xs text-gray-500 font-mono space-y-1">
+                        <div>
+                          rule: {currentMoveAnalysis.trace.rule}
+                          {currentMoveAnalysis.trace.threshold !== undefined && ` (${currentMoveAnalysis.trace.threshold})`}
+                        </div>
+                        <div>
+                          eval: {currentMoveAnalysis.trace.scoreBefore} → {currentMoveAnalysis.trace.scoreAfter} ({currentMoveAnalysis.trace.evaluationChange})
+                        </div>
+                        {currentMoveAnalysis.trace.notes.map(note => (
+                          <div key={note}>{note}</div>
+                        ))}
+                      </div>
+                    )}
+
+                    {!currentMoveAnalysis.suggestedMove && currentMoveAnalysis.alternativeMoves && currentMoveAnalysis.alternativeMoves.length > 0 && (
                       <div>
                         <div className="text-sm font-medium text-gray-700 mb-2">Best Move:</div>
                         <div className="text-sm text-gray-600">
@@ -226,6 +269,20 @@ export default function Home() {
               </Card>
             )}
 
+            {/* Engine Line */}
+            {engineLine.length > 0 && (
+              <Card>
+                <CardHeader>
+                  <CardTitle>Engine Line</CardTitle>
+                </CardHeader>
+                <CardContent>
+                  <div className="text-sm text-gray-700 font-mono">
+                    {engineLine.map(ply => ply.san).join(' ')}
+                  </div>
+                </CardContent>
+              </Card>
+            )}
+
             {/* Game Controls */}
             {gameState && (
               <GameControls
@@ -248,14 +305,14 @@ export default function Home() {
                 <CardHeader>
                   <CardTitle>Import Game</CardTitle>
                   <CardDescription>
-                    Paste a PGN game to start analysis
+                    Paste a PGN game or a Lichess game URL to start analysis
                   </CardDescription>
                 </CardHeader>
                 <CardContent>
                   <div className="space-y-4">
                     <Textarea
-                      label="Paste PGN here"
-                      placeholder="Paste your PGN game notation here..."
+                      label="Paste PGN or Lichess game URL here"
+                      placeholder="Paste PGN notation or a Lichess game URL (e.g. https://lichess.org/abcdEFGH)..."
                       value={pgnInput}
                       onChange={(e) => setPgnInput(e.target.value)}
                       rows={8}
@@ -2,7 +2,7 @@
 
 import { EngineEvaluation } from '@/types/analysis';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/Card';
-import { convertScoreToString } from '@/utils/stockfish';
+import { assessPosition, convertScoreToString, getScoreColor } from '@/utils/stockfish';
 
 interface EvaluationChartProps {
   evaluations: EngineEvaluation[];
@@ -77,10 +77,7 @@ export function EvaluationChart({
           <CardTitle>Game Evaluation</CardTitle>
           <div className="text-sm">
             <span className="text-gray-500">Current: </span>
-            <span className={`font-bold ${
-              currentScore > 50 ? 'text-green-600' : 
-              currentScore < -50 ? 'text-red-600' : 'text-gray-600'
-            }`}>
+            <span className={`font-bold ${getScoreColor(currentScore)}`}>
               {currentEval ? convertScoreToString(currentEval.score, currentEval.mate) : '0.0'}
             </span>
           </div>
@@ -228,7 +225,7 @@ export function EvaluationChart({
             </div>
             <div className="text-center">
               <div className="font-medium text-gray-900">
-                {scores.filter(s => Math.abs(s) < 50).length}
+                {scores.filter(s => assessPosition(s) === 'equal').length}
               </div>
               <div className="text-gray-500">Equal Positions</div>
             </div>
@@ -2,6 +2,7 @@
 
 import { GameAnalysis } from '@/types/analysis';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/Card';
+import { formatAccuracy } from '@/utils/stockfish';
 
 interface GameSummaryProps {
   gameAnalysis: GameAnalysis;
@@ -35,7 +36,11 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
   // Calculate game statistics
   const whiteAccuracy = gameAnalysis.whiteStats.accuracy;
   const blackAccuracy = gameAnalysis.blackStats.accuracy;
-  const averageAccuracy = (whiteAccuracy + blackAccuracy) / 2;
+  // Players with no scored moves are left out of the average
+  const scoredAccuracies = [whiteAccuracy, blackAccuracy].filter((accuracy): accuracy is number => accuracy !== undefined);
+  const averageAccuracy = scoredAccuracies.length > 0
+    ? scoredAccuracies.reduce((sum, accuracy) => sum + accuracy, 0) / scoredAccuracies.length
+    : undefined;
   
   // Game phase statistics
   const openingLength = gameAnalysis.gamePhases.opening;
@@ -75,6 +80,13 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
       </CardHeader>
       
       <CardContent className="space-y-6">
+        {/* Review Summary */}
+        {gameAnalysis.summary && (
+          <div className="text-sm text-gray-700 leading-relaxed">
+            {gameAnalysis.summary}
+          </div>
+        )}
+
         {/* Game Information */}
         <div>
           <div className="text-sm font-medium text-gray-700 mb-3">Game Information</div>
@@ -122,9 +134,14 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
               <div className="flex justify-between items-center">
                 <span className="text-sm text-gray-500">Opening Accuracy:</span>
                 <span className="text-sm font-medium">
-                  {gameAnalysis.phaseAnalysis?.openingAccuracy.toFixed(1)}%
+                  {formatAccuracy(gameAnalysis.phaseAnalysis?.openingAccuracy)}
                 </span>
               </div>
+              {gameAnalysis.openingAnalysis.trap && (
+                <div className="text-sm text-red-600">
+                  {gameAnalysis.openingAnalysis.trap.description}
+                </div>
+              )}
             </div>
           </div>
         )}
@@ -141,7 +158,7 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
               <div className="text-right">
                 <div className="text-sm font-medium">{openingLength} moves</div>
                 <div className="text-xs text-gray-500">
-                  {gameAnalysis.phaseAnalysis?.openingAccuracy.toFixed(1)}% accuracy
+                  {formatAccuracy(gameAnalysis.phaseAnalysis?.openingAccuracy)} accuracy
                 </div>
               </div>
             </div>
@@ -154,7 +171,7 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
               <div className="text-right">
                 <div className="text-sm font-medium">{middlegameLength} moves</div>
                 <div className="text-xs text-gray-500">
-                  {gameAnalysis.phaseAnalysis?.middlegameAccuracy.toFixed(1)}% accuracy
+                  {formatAccuracy(gameAnalysis.phaseAnalysis?.middlegameAccuracy)} accuracy
                 </div>
               </div>
             </div>
@@ -167,13 +184,72 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
               <div className="text-right">
                 <div className="text-sm font-medium">{endgameLength} moves</div>
                 <div className="text-xs text-gray-500">
-                  {gameAnalysis.phaseAnalysis?.endgameAccuracy.toFixed(1)}% accuracy
+                  {formatAccuracy(gameAnalysis.phaseAnalysis?.endgameAccuracy)} accuracy
                 </div>
               </div>
             </div>
           </div>
         </div>
 
+        {/* Decisive Moment */}
+        {gameAnalysis.decisiveMoment && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Decisive Moment</div>
+            <div className="text-sm text-gray-700">
+              {gameAnalysis.decisiveMoment.description}
+            </div>
+          </div>
+        )}
+
+        {/* Missed Opportunities */}
+        {gameAnalysis.missedOpportunities && gameAnalysis.missedOpportunities.length > 0 && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Missed Opportunities</div>
+            <div className="space-y-2">
+              {gameAnalysis.missedOpportunities.map((opportunity) => (
+                <div key={`${opportunity.moveNumber}-${opportunity.advantage}`} className="text-sm text-gray-700">
+                  {opportunity.description}
+                </div>
+              ))}
+            </div>
+          </div>
+        )}
+
+        {/* Repetitions */}
+        {gameAnalysis.repetitions?.description && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Draw Claims</div>
+            <div className="text-sm text-gray-700">
+              {gameAnalysis.repetitions.description}
+            </div>
+          </div>
+        )}
+
+        {/* Result Consistency */}
+        {gameAnalysis.resultConsistency && !gameAnalysis.resultConsistency.isConsistent && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Result Check</div>
+            <div className="text-sm text-yellow-700">
+              {gameAnalysis.resultConsistency.note}
+            </div>
+          </div>
+        )}
+
+        {/* Endgame Verdict */}
+        {gameAnalysis.endgameVerdict && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Endgame Verdict</div>
+            <div className="flex justify-between items-center">
+              <span className="text-sm text-gray-500">
+                {gameAnalysis.endgameVerdict.player === 'white' ? gameInfo.white : gameInfo.black}:
+              </span>
+              <span className="text-sm font-medium text-red-600">
+                {gameAnalysis.endgameVerdict.description}
+              </span>
+            </div>
+          </div>
+        )}
+
         {/* Performance Overview */}
         <div className="border-t pt-4">
           <div className="text-sm font-medium text-gray-700 mb-3">Performance Overview</div>
@@ -181,9 +257,17 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
             <div className="flex justify-between items-center">
               <span className="text-sm text-gray-500">Average Accuracy:</span>
               <span className="text-sm font-bold text-blue-600">
-                {averageAccuracy.toFixed(1)}%
+                {formatAccuracy(averageAccuracy)}
               </span>
             </div>
+            {gameAnalysis.gameQuality !== undefined && (
+              <div className="flex justify-between items-center">
+                <span className="text-sm text-gray-500">Game Quality:</span>
+                <span className="text-sm font-medium">
+                  {gameAnalysis.gameQuality.toFixed(1)}
+                </span>
+              </div>
+            )}
             <div className="flex justify-between items-center">
               <span className="text-sm text-gray-500">Critical Moments:</span>
               <span className="text-sm font-medium">
@@ -205,6 +289,31 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
           </div>
         </div>
 
+        {/* Engine Effort */}
+        {gameAnalysis.engineStats && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Engine Effort</div>
+            <div className="space-y-2">
+              <div className="flex justify-between items-center">
+                <span className="text-sm text-gray-500">Positions Analyzed:</span>
+                <span className="text-sm font-medium">{gameAnalysis.engineStats.positionsAnalyzed}</span>
+              </div>
+              <div className="flex justify-between items-center">
+                <span className="text-sm text-gray-500">Average Depth:</span>
+                <span className="text-sm font-medium">{gameAnalysis.engineStats.averageDepth}</span>
+              </div>
+              <div className="flex justify-between items-center">
+                <span className="text-sm text-gray-500">Total Nodes:</span>
+                <span className="text-sm font-medium">{gameAnalysis.engineStats.totalNodes.toLocaleString()}</span>
+              </div>
+              <div className="flex justify-between items-center">
+                <span className="text-sm text-gray-500">Engine Time:</span>
+                <span className="text-sm font-medium">{(gameAnalysis.engineStats.totalTime / 1000).toFixed(1)}s</span>
+              </div>
+            </div>
+          </div>
+        )}
+
         {/* Winner Analysis */}
         {winner && winner !== 'draw' && (
           <div className="border-t pt-4">
@@ -219,7 +328,7 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
               <div className="flex justify-between items-center">
                 <span className="text-sm text-gray-500">Winner Accuracy:</span>
                 <span className="text-sm font-medium">
-                  {winner === 'white' ? whiteAccuracy.toFixed(1) : blackAccuracy.toFixed(1)}%
+                  {formatAccuracy(winner === 'white' ? whiteAccuracy : blackAccuracy)}
                 </span>
               </div>
               {gameAnalysis.gameResult?.winningAdvantage && (
@@ -235,25 +344,27 @@ export function GameSummary({ gameAnalysis, gameInfo }: GameSummaryProps) {
         )}
 
         {/* Game Quality Assessment */}
-        <div className="border-t pt-4">
-          <div className="text-sm font-medium text-gray-700 mb-3">Game Quality</div>
-          <div className="flex justify-center">
-            <div className="text-center">
-              <div className={`text-2xl font-bold ${
-                averageAccuracy >= 90 ? 'text-green-600' :
-                averageAccuracy >= 85 ? 'text-blue-600' :
-                averageAccuracy >= 80 ? 'text-yellow-600' :
-                averageAccuracy >= 75 ? 'text-orange-600' : 'text-red-600'
-              }`}>
-                {averageAccuracy >= 90 ? 'Excellent' :
-                 averageAccuracy >= 85 ? 'Very Good' :
-                 averageAccuracy >= 80 ? 'Good' :
-                 averageAccuracy >= 75 ? 'Fair' : 'Poor'}
+        {averageAccuracy !== undefined && (
+          <div className="border-t pt-4">
+            <div className="text-sm font-medium text-gray-700 mb-3">Game Quality</div>
+            <div className="flex justify-center">
+              <div className="text-center">
+                <div className={`text-2xl font-bold ${
+                  averageAccuracy >= 90 ? 'text-green-600' :
+                  averageAccuracy >= 85 ? 'text-blue-600' :
+                  averageAccuracy >= 80 ? 'text-yellow-600' :
+                  averageAccuracy >= 75 ? 'text-orange-600' : 'text-red-600'
+                }`}>
+                  {averageAccuracy >= 90 ? 'Excellent' :
+                   averageAccuracy >= 85 ? 'Very Good' :
+                   averageAccuracy >= 80 ? 'Good' :
+                   averageAccuracy >= 75 ? 'Fair' : 'Poor'}
+                </div>
+                <div className="text-sm text-gray-500">Overall Quality</div>
               </div>
-              <div className="text-sm text-gray-500">Overall Quality</div>
             </div>
           </div>
-        </div>
+        )}
       </CardContent>
     </Card>
   );
@@ -2,6 +2,7 @@
 
 import { PlayerStatistics } from '@/types/analysis';
 import { Card, CardContent, CardHeader, CardTitle } from '@/components/ui/Card';
+import { CLASSIFICATION_DISPLAY, MOVE_CLASSIFICATIONS, formatAccuracy } from '@/utils/stockfish';
 
 interface PlayerStatsProps {
   playerName: string;
@@ -22,7 +23,8 @@ export function PlayerStats({
   const bgColor = color === 'white' ? 'bg-gray-100' : 'bg-gray-800';
   const textColor = color === 'white' ? 'text-gray-900' : 'text-white';
 
-  const getAccuracyColor = (accuracy: number) => {
+  const getAccuracyColor = (accuracy: number | undefined) => {
+    if (accuracy === undefined) return 'text-gray-400';
     if (accuracy >= 95) return 'text-green-600';
     if (accuracy >= 90) return 'text-blue-600';
     if (accuracy >= 85) return 'text-yellow-600';
@@ -30,7 +32,8 @@ export function PlayerStats({
     return 'text-red-600';
   };
 
-  const getAccuracyBadge = (accuracy: number) => {
+  const getAccuracyBadge = (accuracy: number | undefined) => {
+    if (accuracy === undefined) return 'bg-gray-100 text-gray-600';
     if (accuracy >= 95) return 'bg-green-100 text-green-800';
     if (accuracy >= 90) return 'bg-blue-100 text-blue-800';
     if (accuracy >= 85) return 'bg-yellow-100 text-yellow-800';
@@ -70,7 +73,7 @@ export function PlayerStats({
           </div>
           <div className="text-right">
             <div className={`text-2xl font-bold ${getAccuracyColor(statistics.accuracy)}`}>
-              {statistics.accuracy.toFixed(1)}%
+              {formatAccuracy(statistics.accuracy)}
             </div>
             <div className={`text-xs px-2 py-1 rounded-full font-medium ${getAccuracyBadge(statistics.accuracy)}`}>
               Accuracy
@@ -84,16 +87,12 @@ export function PlayerStats({
         <div>
           <div className="text-sm font-medium text-gray-700 mb-3">Move Quality</div>
           <div className="space-y-2">
-            {[
-              { type: 'brilliant', count: statistics.brilliant, color: 'bg-cyan-500', label: 'Brilliant' },
-              { type: 'great', count: statistics.great, color: 'bg-blue-500', label: 'Great' },
-              { type: 'best', count: statistics.best, color: 'bg-green-500', label: 'Best' },
-              { type: 'good', count: statistics.good, color: 'bg-green-400', label: 'Good' },
-              { type: 'inaccuracy', count: statistics.inaccuracy, color: 'bg-yellow-500', label: 'Inaccuracy' },
-              { type: 'mistake', count: statistics.mistake, color: 'bg-orange-500', label: 'Mistake' },
-              { type: 'blunder', count: statistics.blunder, color: 'bg-red-500', label: 'Blunder' },
-              { type: 'miss', count: statistics.miss, color: 'bg-red-600', label: 'Miss' }
-            ].filter(item => item.count > 0).map(({ type, count, color, label }) => (
+            {MOVE_CLASSIFICATIONS.map(type => ({
+              type,
+              count: statistics[type],
+              color: CLASSIFICATION_DISPLAY[type].dotColor,
+              label: CLASSIFICATION_DISPLAY[type].label
+            })).filter(item => item.count > 0).map(({ type, count, color, label }) => (
               <div key={type} className="flex justify-between items-center">
                 <div className="flex items-center space-x-2">
                   <div className={`w-3 h-3 ${color} rounded-full`}></div>
@@ -157,6 +156,12 @@ export function PlayerStats({
                 {statistics.inaccuracy + statistics.mistake + statistics.blunder + statistics.miss}
               </span>
             </div>
+            {statistics.conversionTechnique !== undefined && (
+              <div className="flex justify-between items-center">
+                <span className="text-sm">Conversion Technique</span>
+                <span className="text-sm font-medium">{statistics.conversionTechnique.toFixed(1)}%</span>
+              </div>
+            )}
           </div>
         </div>
       </CardContent>
@@ -3,6 +3,11 @@
 import React from 'react';
 import { ChessMove } from '@/types/chess';
 
+interface IndexedMove {
+  move: ChessMove;
+  index: number;
+}
+
 interface MoveListProps {
   moves: ChessMove[];
   currentMoveIndex: number;
@@ -24,21 +29,20 @@ export default function MoveList({
     );
   }
 
-  // Group moves by pairs (white and black)
-  const movePairs: { white: ChessMove; black?: ChessMove; moveNumber: number }[] = [];
+  // Group moves by pairs (white and black). Games set up from a FEN may start with Black.
+  const movePairs: { white?: IndexedMove; black?: IndexedMove; moveNumber: number }[] = [];
   
-  for (let i = 0; i < moves.length; i += 2) {
-    const whiteMove = moves[i];
-    const blackMove = moves[i + 1];
+  moves.forEach((move, index) => {
+    const lastPair = movePairs[movePairs.length - 1];
     
-    if (whiteMove && whiteMove.color === 'w') {
-      movePairs.push({
-        white: whiteMove,
-        black: blackMove && blackMove.color === 'b' ? blackMove : undefined,
-        moveNumber: whiteMove.moveNumber
-      });
+    if (move.color === 'b' && lastPair && !lastPair.black && lastPair.moveNumber === move.moveNumber) {
+      lastPair.black = { move, index };
+    } else if (move.color === 'w') {
+      movePairs.push({ white: { move, index }, moveNumber: move.moveNumber });
+    } else {
+      movePairs.push({ black: { move, index }, moveNumber: move.moveNumber });
     }
-  }
+  });
 
   return (
     <div className={`bg-white border border-gray-200 rounded-lg ${className}`}>
@@ -56,28 +60,32 @@ export default function MoveList({
               </div>
               
               {/* White move */}
-              <button
-                onClick={() => onMoveClick(pairIndex * 2)}
-                className={`px-2 py-1 rounded hover:bg-gray-100 transition-colors min-w-16 text-left ${
-                  currentMoveIndex === pairIndex * 2
-                    ? 'bg-green-100 text-green-800 font-semibold'
-                    : 'text-gray-700 hover:text-gray-900'
-                }`}
-              >
-                {pair.white.san}
-              </button>
+              {pair.white ? (
+                <button
+                  onClick={() => onMoveClick(pair.white!.index)}
+                  className={`px-2 py-1 rounded hover:bg-gray-100 transition-colors min-w-16 text-left ${
+                    currentMoveIndex === pair.white.index
+                      ? 'bg-green-100 text-green-800 font-semibold'
+                      : 'text-gray-700 hover:text-gray-900'
+                  }`}
+                >
+                  {pair.white.move.san}
+                </button>
+              ) : (
+                <div className="px-2 py-1 min-w-16 text-gray-400">...</div>
+              )}
               
               {/* Black move */}
               {pair.black && (
                 <button
-                  onClick={() => onMoveClick(pairIndex * 2 + 1)}
+                  onClick={() => onMoveClick(pair.black!.index)}
                   className={`px-2 py-1 rounded hover:bg-gray-100 transition-colors min-w-16 text-left ${
-                    currentMoveIndex === pairIndex * 2 + 1
+                    currentMoveIndex === pair.black.index
                       ? 'bg-green-100 text-green-800 font-semibold'
                       : 'text-gray-700 hover:text-gray-900'
                   }`}
                 >
-                  {pair.black.san}
+                  {pair.black.move.san}
                 </button>
               )}
             </div>
@@ -2,7 +2,8 @@
 
 import { useState, useCallback, useEffect } from 'react';
 import { GameState, ChessMove } from '@/types/chess';
-import { ChessGameManager } from '@/utils/chess';
+import { ChessGameManager, STARTING_FEN } from '@/utils/chess';
+import { fetchGamePgn, isGameUrl } from '@/utils/gameImport';
 
 export function useChessGame() {
   const [gameState, setGameState] = useState<GameState | null>(null);
@@ -16,8 +17,9 @@ export function useChessGame() {
     setError(null);
     
     try {
+      const gamePgn = isGameUrl(pgn) ? await fetchGamePgn(pgn) : pgn;
       const manager = new ChessGameManager();
-      const state = manager.loadPGN(pgn);
+      const state = manager.loadPGN(gamePgn);
       
       setGameManager(manager);
       setGameState(state);
@@ -63,7 +65,7 @@ export function useChessGame() {
 
   const getCurrentPosition = useCallback(() => {
     if (!gameManager) {
-      return 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1';
+      return STARTING_FEN;
     }
     return gameManager.getPosition(currentMoveIndex);
   }, [gameManager, currentMoveIndex]);
@@ -4,11 +4,26 @@ import { useState, useCallback, useEffect } from 'react';
 import { useChessGame } from './useChessGame';
 import { useStockfish } from './useStockfish';
 import { GameAnalysis, MoveAnalysis, PlayerStatistics, EngineEvaluation } from '@/types/analysis';
-import { ChessGameManager } from '@/utils/chess';
+import {
+  ChessGameManager,
+  STARTING_FEN,
+  detectRepetitions,
+  isDeadDraw,
+  isWhiteToMove,
+  playUciLine
+} from '@/utils/chess';
+import { analyzeMoves } from '@/utils/moveAnalysis';
+import { calculateEngineStats } from '@/utils/stockfish';
+import { generateGameSummary } from '@/utils/summary';
+import { VariationMove } from '@/types/chess';
 
-export function useGameAnalysis() {
+interface GameAnalysisOptions {
+  debug?: boolean; // Record a per-move classification trace
+}
+
+export function useGameAnalysis({ debug = false }: GameAnalysisOptions = {}) {
   const chessGame = useChessGame();
-  const stockfish = useStockfish({ depth: 15, time: 1000 });
+  const stockfish = useStockfish();
   
   const [gameAnalysis, setGameAnalysis] = useState<GameAnalysis | null>(null);
   const [isAnalyzingGame, setIsAnalyzingGame] = useState(false);
@@ -26,16 +41,30 @@ export function useGameAnalysis() {
       return;
     }
 
+    // Reject oversized games up front rather than tying up the engine
+    try {
+      stockfish.engine?.validateGameLength(chessGame.gameState.moves.length);
+    } catch (error) {
+      setAnalysisError(error instanceof Error ? error.message : 'Game is too long to analyze');
+      return;
+    }
+
     setIsAnalyzingGame(true);
     setAnalysisError(null);
 
     try {
+      const engine = stockfish.engine;
+      if (!engine) {
+        throw new Error('Stockfish engine not ready');
+      }
+
       const { moves } = chessGame.gameState;
       const gameManager = new ChessGameManager();
+      const startingFen = chessGame.gameState.startingFen || STARTING_FEN;
       
       // Get all positions in the game
       const positions: string[] = [];
-      positions.push('rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1'); // Starting position
+      positions.push(startingFen); // Starting position
       
       // Load the game and get positions after each move
       gameManager.loadPGN(chessGame.gameState.pgn);
@@ -45,61 +74,48 @@ export function useGameAnalysis() {
       }
 
       // Analyze all positions
-      const evaluations = await stockfish.analyzeGame(positions, (progress) => {
+      const rawEvaluations = await stockfish.analyzeGame(positions, (progress) => {
         // Progress callback could be used to update UI
         console.log(`Analysis progress: ${progress.progress.toFixed(1)}%`);
       });
 
+      // Dead-drawn positions are pinned to equal so engine noise can't manufacture errors
+      const deadDraws = positions.map(isDeadDraw);
+      const evaluations = rawEvaluations.map((evaluation, i) =>
+        deadDraws[i] ? { ...evaluation, score: 0, mate: undefined } : evaluation
+      );
+
       if (evaluations.length === 0) {
         throw new Error('Analysis failed - no evaluations received');
       }
 
       // Process move analysis
-      const moveAnalyses: MoveAnalysis[] = [];
-      
-      for (let i = 0; i < moves.length; i++) {
-        const move = moves[i];
-        const positionBefore = evaluations[i];
-        const positionAfter = evaluations[i + 1];
-        
-        if (positionBefore && positionAfter) {
-          const bestMove = positionBefore.bestMove;
-          const classification = stockfish.classifyMove(
-            positionBefore,
-            positionAfter,
-            move.from + move.to,
-            bestMove
-          );
-
-          const moveAnalysis: MoveAnalysis = {
-            move: move.from + move.to,
-            san: move.san,
-            evaluation: positionAfter,
-            classification,
-            alternativeMoves: [{
-              move: bestMove,
-              evaluation: positionBefore
-            }]
-          };
-
-          moveAnalyses.push(moveAnalysis);
-        }
-      }
+      const moveAnalyses = await analyzeMoves(engine, moves, positions, evaluations, debug);
 
       // Calculate player statistics
-      const whiteStats = calculatePlayerStats(moveAnalyses.filter((_, i) => i % 2 === 0));
-      const blackStats = calculatePlayerStats(moveAnalyses.filter((_, i) => i % 2 === 1));
+      // Side to move comes from the positions themselves, since games set up from a FEN may start with Black
+      const whiteStats = calculatePlayerStats(moveAnalyses.filter((_, i) => moves[i].color === 'w'));
+      const blackStats = calculatePlayerStats(moveAnalyses.filter((_, i) => moves[i].color === 'b'));
 
       // Calculate accuracies
-      const whiteEvaluations = evaluations.filter((_, i) => i % 2 === 0);
-      const blackEvaluations = evaluations.filter((_, i) => i % 2 === 1);
-      
-      whiteStats.accuracy = stockfish.calculateAccuracy(whiteEvaluations);
-      blackStats.accuracy = stockfish.calculateAccuracy(blackEvaluations);
+      // Moves played in dead-drawn positions don't count towards accuracy
+      const whiteMovesFirst = isWhiteToMove(startingFen);
+      whiteStats.accuracy = stockfish.calculateAccuracy(evaluations, true, whiteMovesFirst, deadDraws);
+      blackStats.accuracy = stockfish.calculateAccuracy(evaluations, false, whiteMovesFirst, deadDraws);
+      whiteStats.conversionTechnique = stockfish.engine?.calculateConversionTechnique(
+        evaluations,
+        true,
+        whiteMovesFirst
+      );
+      blackStats.conversionTechnique = stockfish.engine?.calculateConversionTechnique(
+        evaluations,
+        false,
+        whiteMovesFirst
+      );
 
       // Detect critical moments and analyze game phases
       const criticalMoments = stockfish.engine?.detectCriticalMoments(evaluations) || [];
-      const phaseAnalysis = stockfish.engine?.analyzeGamePhases(moves, evaluations) || {
+      const phaseAnalysis = stockfish.engine?.analyzeGamePhases(moves, evaluations, whiteMovesFirst, deadDraws) || {
         opening: Math.min(10, moves.length),
         middlegame: Math.min(25, moves.length), 
         endgame: moves.length,
@@ -107,6 +123,18 @@ export function useGameAnalysis() {
         middlegameAccuracy: whiteStats.accuracy,
         endgameAccuracy: whiteStats.accuracy
       };
+      const endgameVerdict = stockfish.engine?.detectEndgameVerdict(
+        evaluations,
+        moves,
+        phaseAnalysis.middlegame
+      );
+      const decisiveMoment = stockfish.engine?.detectDecisiveMoment(evaluations, moves);
+      const missedOpportunities = stockfish.engine?.detectMissedOpportunities(evaluations, moves) || [];
+      const resultConsistency = stockfish.engine?.checkResultConsistency(
+        evaluations,
+        chessGame.gameState.gameInfo.result,
+        chessGame.gameState.gameInfo.termination
+      );
 
       // Calculate tactical statistics
       whiteStats.tacticalMoves = 0;
@@ -129,7 +157,7 @@ export function useGameAnalysis() {
           
           moveAnalysis.tacticalAnalysis = tacticalAnalysis;
           
-          const isWhiteMove = i % 2 === 0;
+          const isWhiteMove = moves[i].color === 'w';
           if (isWhiteMove) {
             if (tacticalAnalysis.isTactical) whiteStats.tacticalMoves!++;
             if (tacticalAnalysis.isForcing) whiteStats.forcingMoves!++;
@@ -148,7 +176,8 @@ export function useGameAnalysis() {
         openingAnalysis: {
           name: chessGame.gameState.gameInfo.opening || 'Unknown',
           eco: chessGame.gameState.gameInfo.eco || '',
-          accuracy: Math.max(whiteStats.accuracy, blackStats.accuracy)
+          accuracy: phaseAnalysis.openingAccuracy,
+          trap: stockfish.engine?.detectOpeningTrap(moveAnalyses, moves, evaluations)
         },
         gamePhases: {
           opening: phaseAnalysis.opening,
@@ -166,8 +195,21 @@ export function useGameAnalysis() {
           result: chessGame.gameState.gameInfo.result as any || '*',
           termination: chessGame.gameState.gameInfo.termination || 'Unknown',
           winningAdvantage: Math.max(...evaluations.map(e => Math.abs(e.score)))
-        }
+        },
+        endgameVerdict,
+        resultConsistency,
+        engineStats: calculateEngineStats(rawEvaluations),
+        decisiveMoment,
+        missedOpportunities,
+        repetitions: detectRepetitions(startingFen, moves),
+        gameQuality: stockfish.engine?.calculateGameQuality(
+          whiteStats.accuracy,
+          blackStats.accuracy,
+          evaluations,
+          criticalMoments
+        )
       };
+      analysis.summary = generateGameSummary(analysis, moves, chessGame.gameState.gameInfo);
 
       setGameAnalysis(analysis);
       
@@ -178,11 +220,10 @@ export function useGameAnalysis() {
     } finally {
       setIsAnalyzingGame(false);
     }
-  }, [chessGame.gameState, stockfish]);
+  }, [chessGame.gameState, stockfish, debug]);
 
   const calculatePlayerStats = (playerMoves: MoveAnalysis[]): PlayerStatistics => {
     const stats: PlayerStatistics = {
-      accuracy: 0,
       brilliant: 0,
       great: 0,
       best: 0,
@@ -216,6 +257,20 @@ export function useGameAnalysis() {
     return moveAnalysis?.evaluation || null;
   }, [getMoveAnalysis]);
 
+  const getPrincipalVariation = useCallback((moveIndex: number): VariationMove[] => {
+    if (!gameAnalysis || !chessGame.gameState) return [];
+
+    // evaluationHistory[0] is the starting position, so the position after move i is at i + 1
+    const evaluation = gameAnalysis.evaluationHistory[moveIndex + 1];
+    if (!evaluation) return [];
+
+    const fen = moveIndex < 0
+      ? chessGame.gameState.startingFen || STARTING_FEN
+      : chessGame.gameState.moves[moveIndex].fen;
+
+    return playUciLine(fen, evaluation.principalVariation);
+  }, [gameAnalysis, chessGame.gameState]);
+
   const stopAnalysis = useCallback(() => {
     stockfish.stopAnalysis();
     setIsAnalyzingGame(false);
@@ -259,10 +314,11 @@ export function useGameAnalysis() {
     getMoveAnalysis,
     getCurrentMoveAnalysis,
     getPositionEvaluation,
+    getPrincipalVariation,
     
     // Computed values
-    whiteAccuracy: gameAnalysis?.whiteStats.accuracy || 0,
-    blackAccuracy: gameAnalysis?.blackStats.accuracy || 0,
+    whiteAccuracy: gameAnalysis?.whiteStats.accuracy,
+    blackAccuracy: gameAnalysis?.blackStats.accuracy,
     currentMoveAnalysis: getCurrentMoveAnalysis(),
   };
 } 
\ No newline at end of file
@@ -162,7 +162,8 @@ export function useStockfish(config?: Partial<StockfishConfig>) {
     positionBefore: EngineEvaluation,
     positionAfter: EngineEvaluation,
     playedMove: string,
-    bestMove: string
+    bestMove: string,
+    isWhiteMove?: boolean
   ) => {
     if (!engineRef.current) return 'good';
     
@@ -170,13 +171,19 @@ export function useStockfish(config?: Partial<StockfishConfig>) {
       positionBefore,
       positionAfter,
       playedMove,
-      bestMove
+      bestMove,
+      isWhiteMove
     );
   }, []);
 
-  const calculateAccuracy = useCallback((evaluations: EngineEvaluation[]): number => {
-    if (!engineRef.current) return 0;
-    return engineRef.current.calculateAccuracy(evaluations);
+  const calculateAccuracy = useCallback((
+    evaluations: EngineEvaluation[],
+    isWhite: boolean,
+    whiteMovesFirst?: boolean,
+    skipPositions?: boolean[]
+  ): number | undefined => {
+    if (!engineRef.current) return undefined;
+    return engineRef.current.calculateAccuracy(evaluations, isWhite, whiteMovesFirst, skipPositions);
   }, []);
 
   // Auto-initialize on mount
@@ -40,7 +40,8 @@ export interface EngineEvaluation {
   principalVariation: string[];
   nodes: number;
   time: number;
-  mate?: number; // Mate in X moves
+  mate?: number; // Mate in X moves; 0 when the side to move is already checkmated
+  confidence?: number; // 0-1, how far the search can be trusted given depth and nodes
 }
 
 export interface MoveAnalysis {
@@ -54,10 +55,30 @@ export interface MoveAnalysis {
     evaluation: EngineEvaluation;
   }[];
   comment?: string;
+  suggestedMove?: SuggestedMove;
+  provisional?: boolean; // Classification rests on a low-confidence evaluation
+  trace?: ClassificationTrace; // Only recorded in debug mode
+}
+
+// Why a move got its classification, for debugging the rules
+export interface ClassificationTrace {
+  rule: string;
+  scoreBefore: number; // From the mover's perspective
+  scoreAfter: number;
+  evaluationChange: number;
+  threshold?: number; // Boundary of the rule that fired: centipawns, or win percentage points lost under the lichess model
+  notes: string[];
+}
+
+export interface SuggestedMove {
+  move: string; // UCI notation
+  san: string;
+  line: string[]; // Engine continuation in SAN, starting with the suggested move
+  improvement: number; // Win percentage points gained over the move played
 }
 
 export interface PlayerStatistics {
-  accuracy: number;
+  accuracy?: number; // Undefined when none of the player's moves could be scored
   brilliant: number;
   great: number;
   best: number;
@@ -70,6 +91,7 @@ export interface PlayerStatistics {
   tacticalMoves?: number;
   forcingMoves?: number;
   criticalMoments?: number;
+  conversionTechnique?: number; // 0-100, how cleanly a winning position was converted
 }
 
 export interface GameAnalysis {
@@ -79,7 +101,8 @@ export interface GameAnalysis {
   openingAnalysis?: {
     name: string;
     eco: string;
-    accuracy: number;
+    accuracy?: number;
+    trap?: OpeningTrap;
   };
   middlegameAnalysis?: {
     accuracy: number;
@@ -98,15 +121,46 @@ export interface GameAnalysis {
   criticalMoments: number[];
   evaluationHistory: EngineEvaluation[];
   phaseAnalysis: {
-    openingAccuracy: number;
-    middlegameAccuracy: number;
-    endgameAccuracy: number;
+    // Undefined for a phase with no scored moves
+    openingAccuracy?: number;
+    middlegameAccuracy?: number;
+    endgameAccuracy?: number;
   };
   gameResult?: {
     result: '1-0' | '0-1' | '1/2-1/2' | '*';
     termination: string;
     winningAdvantage?: number; // Max advantage achieved
   };
+  endgameVerdict?: EndgameVerdict;
+  resultConsistency?: ResultConsistency;
+  summary?: string; // Plain-language recap of the game
+  engineStats?: EngineStats;
+  decisiveMoment?: CriticalPosition; // Largest swing that was never recovered
+  missedOpportunities?: CriticalPosition[]; // Opponent errors that were not punished
+  gameQuality?: number; // 0-100, how cleanly and sharply both sides played
+  repetitions?: RepetitionInfo;
+}
+
+export interface RepetitionInfo {
+  repeatedMoves: number[]; // Move indexes that recreate an earlier position
+  threefoldAt?: number; // Move index where a threefold repetition draw became claimable
+  fiftyMoveAt?: number; // Move index where the fifty-move rule became claimable
+  description?: string;
+}
+
+export interface OpeningTrap {
+  moveNumber: number;
+  player: 'white' | 'black';
+  move: string; // SAN of the move that fell into the trap
+  refutation: string; // SAN of the forcing reply that punished it
+  description: string;
+}
+
+export interface EngineStats {
+  totalNodes: number;
+  totalTime: number; // Milliseconds
+  averageDepth: number;
+  positionsAnalyzed: number;
 }
 
 export interface StockfishConfig {
@@ -114,8 +168,17 @@ export interface StockfishConfig {
   time: number; // Time limit in milliseconds
   threads: number;
   hash: number; // Hash table size in MB
+  maxDepth?: number; // Upper bound for requested search depth
+  maxTime?: number; // Upper bound for requested time limit in milliseconds
+  maxAnalysisMoves?: number; // Upper bound for full moves in an analyzed game
+  openingBookDepth?: number; // Plies treated as the opening phase
+  accuracyModel?: AccuracyModel;
+  criticalMomentThreshold?: number; // Evaluation swing in centipawns that marks a critical moment
+  decidedPositionWeight?: number; // 0-1 accuracy weight for moves in already-decided positions; 1 disables
 }
 
+export type AccuracyModel = 'chesscom' | 'lichess';
+
 export interface AnalysisProgress {
   currentMove: number;
   totalMoves: number;
@@ -123,6 +186,20 @@ export interface AnalysisProgress {
   estimatedTimeRemaining?: number; // Seconds
 }
 
+export interface EndgameVerdict {
+  moveNumber: number; // Full move number where the endgame result changed
+  player: 'white' | 'black'; // Player who let the result slip
+  from: 'win' | 'draw';
+  to: 'draw' | 'loss';
+  description: string;
+}
+
+export interface ResultConsistency {
+  isConsistent: boolean;
+  finalEvaluation: number; // Centipawns from white's perspective
+  note?: string;
+}
+
 export interface CriticalPosition {
   moveNumber: number;
   beforeEval: number;
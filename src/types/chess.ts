@@ -34,6 +34,7 @@ export interface GameInfo {
   site?: string;
   opening?: string;
   eco?: string; // Encyclopedia of Chess Openings code
+  termination?: string; // How the game ended, e.g. 'Time forfeit' or 'Player won by resignation'
 }
 
 export interface GameState {
@@ -44,6 +45,12 @@ export interface GameState {
   startingFen?: string;
 }
 
+export interface VariationMove {
+  uci: string;
+  san: string;
+  fen: string; // Position after move
+}
+
 export type BoardOrientation = 'white' | 'black';
 
 export interface BoardProps {
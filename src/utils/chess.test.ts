@@ -0,0 +1,228 @@
+import { describe, it } from 'node:test';
+import assert from 'node:assert/strict';
+import { EngineEvaluation } from '@/types/analysis';
+import { ChessMove } from '@/types/chess';
+import {
+  ChessGameManager,
+  STARTING_FEN,
+  buildSuggestedMove,
+  detectRepetitions,
+  isDeadDraw,
+  isWhiteToMove,
+  playUciLine,
+  validateFEN
+} from '@/utils/chess';
+import { roundTo, winPercentage } from '@/utils/stockfish';
+
+describe('validateFEN', () => {
+  it('accepts the starting position', () => {
+    assert.deepEqual(validateFEN(STARTING_FEN), { valid: true });
+  });
+
+  it('accepts an en passant square behind a pawn that just double-stepped', () => {
+    const fen = 'rnbqkbnr/pppp1ppp/8/8/3pP3/8/PPP2PPP/RNBQKBNR b KQkq e3 0 3';
+    assert.deepEqual(validateFEN(fen), { valid: true });
+  });
+
+  const malformed: [string, string, string][] = [
+    ['missing fields', 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -', 'FEN must have 6 space-separated fields'],
+    ['seven ranks', 'rnbqkbnr/pppppppp/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1', 'Piece placement must describe 8 ranks'],
+    ['unknown piece', 'rnbqkbnr/pppppppp/8/8/4X3/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1', "Invalid piece character 'X' in rank 4"],
+    ['short rank', 'rnbqkbnr/pppppppp/8/8/7/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1', 'Rank 4 does not have 8 squares'],
+    ['missing black king', 'rnbq1bnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQ - 0 1', 'Each side must have exactly one king'],
+    ['two white kings', 'rnbqkbnr/pppppppp/8/8/4K3/8/PPPPPPPP/RNBQKBNR w kq - 0 1', 'Each side must have exactly one king'],
+    ['nine pawns', 'rnbqkbnr/pppppppp/8/8/4P3/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1', 'A side cannot have more than 8 pawns'],
+    ['pawn on the back rank', 'rnbqkbnP/pppppppp/8/8/8/8/PPPPPPP1/RNBQKBNR w KQq - 0 1', 'Pawns cannot stand on the first or last rank'],
+    ['bad active color', 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR x KQkq - 0 1', "Active color must be 'w' or 'b', got 'x'"],
+    ['garbled castling rights', 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KX - 0 1', "Invalid castling rights 'KX'"],
+    [
+      'castling without the rook',
+      'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBN1 w KQkq - 0 1',
+      "Castling right 'K' requires a king on e1 and a rook on h1"
+    ],
+    [
+      'castling with a moved king',
+      'rnbq1bnr/ppppkppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1',
+      "Castling right 'k' requires a king on e8 and a rook on h8"
+    ],
+    ['en passant off the board', 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq z9 0 1', "Invalid en passant square 'z9'"],
+    [
+      'en passant on the wrong rank',
+      'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq e3 0 1',
+      'En passant square must be on rank 6 when White is to move'
+    ],
+    [
+      'en passant without a double-stepped pawn',
+      'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR b KQkq e3 0 1',
+      'En passant square e3 has no pawn that just moved past it'
+    ],
+    ['negative halfmove clock', 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - -1 1', 'Halfmove clock must be a non-negative integer'],
+    ['zero fullmove number', 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 0', 'Fullmove number must be a positive integer']
+  ];
+
+  for (const [name, fen, error] of malformed) {
+    it(`rejects ${name}`, () => {
+      assert.deepEqual(validateFEN(fen), { valid: false, error });
+    });
+  }
+});
+
+describe('isWhiteToMove', () => {
+  it('reads the side to move from the FEN', () => {
+    assert.equal(isWhiteToMove(STARTING_FEN), true);
+    assert.equal(isWhiteToMove('rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1'), false);
+  });
+});
+
+describe('isDeadDraw', () => {
+  it('flags king against king', () => {
+    assert.equal(isDeadDraw('8/8/4k3/8/8/3K4/8/8 w - - 0 1'), true);
+  });
+
+  it('flags king and bishop against king', () => {
+    assert.equal(isDeadDraw('8/8/4k3/8/8/3KB3/8/8 w - - 0 1'), true);
+  });
+
+  it('flags king and knight against king', () => {
+    assert.equal(isDeadDraw('8/8/4k3/8/8/3KN3/8/8 b - - 0 1'), true);
+  });
+
+  it('does not flag positions with mating material', () => {
+    assert.equal(isDeadDraw('8/8/4k3/8/8/3KR3/8/8 w - - 0 1'), false);
+    assert.equal(isDeadDraw(STARTING_FEN), false);
+  });
+});
+
+describe('buildSuggestedMove', () => {
+  const evaluation = (score: number, extra: Partial<EngineEvaluation> = {}): EngineEvaluation =>
+    ({ score, depth: 20, bestMove: '', principalVariation: [], nodes: 1000000, time: 100, ...extra });
+  const before = evaluation(30, { bestMove: 'e2e4', principalVariation: ['e2e4', 'e7e5', 'g1f3'] });
+
+  it('suggests the engine line after a blunder', () => {
+    const after = evaluation(-300);
+    const suggestion = buildSuggestedMove(STARTING_FEN, before, after, true);
+
+    assert.deepEqual(suggestion, { move: 'e2e4', san: 'e4', line: ['e4', 'e5', 'Nf3'], improvement: 27.9 });
+    assert.equal(suggestion?.improvement, roundTo(winPercentage(30) - winPercentage(-300), 1));
+  });
+
+  it('measures the gain from the side of the player who moved', () => {
+    // Black's best keeps -30 for White, the move played allows +300
+    const blackBefore = evaluation(-30, { bestMove: 'e2e4', principalVariation: ['e2e4'] });
+
+    assert.equal(buildSuggestedMove(STARTING_FEN, blackBefore, evaluation(300), false)?.improvement, 27.9);
+  });
+
+  it('gives a small gain for a slip in an already decided position', () => {
+    const winning = evaluation(900, { bestMove: 'e2e4', principalVariation: ['e2e4'] });
+    const suggestion = buildSuggestedMove(STARTING_FEN, winning, evaluation(600), true);
+
+    assert.equal(suggestion?.improvement, 6.4);
+  });
+
+  it('suggests nothing when the move played lost nothing', () => {
+    assert.equal(buildSuggestedMove(STARTING_FEN, before, evaluation(30), true), undefined);
+  });
+});
+
+describe('detectRepetitions', () => {
+  const afterNf3 = 'rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq -';
+  const afterNf6 = 'rnbqkb1r/pppppppp/5n2/8/8/5N2/PPPPPPPP/RNBQKB1R w KQkq -';
+  const afterNg1 = 'rnbqkb1r/pppppppp/5n2/8/8/8/PPPPPPPP/RNBQKBNR b KQkq -';
+  const afterNg8 = 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq -';
+
+  // Both sides shuffle their king's knight out and back twice
+  const shuffle: [string, string, string, string][] = [
+    ['Nf3', 'g1', 'f3', afterNf3], ['Nf6', 'g8', 'f6', afterNf6], ['Ng1', 'f3', 'g1', afterNg1], ['Ng8', 'f6', 'g8', afterNg8],
+    ['Nf3', 'g1', 'f3', afterNf3], ['Nf6', 'g8', 'f6', afterNf6], ['Ng1', 'f3', 'g1', afterNg1], ['Ng8', 'f6', 'g8', afterNg8]
+  ];
+  const moves = shuffle.map(([san, from, to, position], i): ChessMove => ({
+    from,
+    to,
+    piece: 'n',
+    san,
+    fen: `${position} ${i + 1} ${Math.floor((i + 1) / 2) + 1}`,
+    moveNumber: Math.floor(i / 2) + 1,
+    color: i % 2 === 0 ? 'w' : 'b'
+  }));
+
+  it('flags the moves that repeat a position and where threefold became claimable', () => {
+    const repetitions = detectRepetitions(STARTING_FEN, moves);
+
+    assert.deepEqual(repetitions.repeatedMoves, [3, 4, 5, 6, 7]);
+    assert.equal(repetitions.threefoldAt, 7);
+    assert.equal(repetitions.fiftyMoveAt, undefined);
+    assert.equal(repetitions.description, 'Threefold repetition could be claimed after 4... Ng8');
+  });
+
+  it('reports nothing for a game without repeats', () => {
+    assert.deepEqual(detectRepetitions(STARTING_FEN, moves.slice(0, 3)), { repeatedMoves: [] });
+  });
+});
+
+describe('playUciLine', () => {
+  it('replays a multi-move line with SAN and positions', () => {
+    const line = playUciLine(STARTING_FEN, ['e2e4', 'e7e5', 'g1f3', 'b8c6']);
+
+    assert.deepEqual(line.map(move => move.san), ['e4', 'e5', 'Nf3', 'Nc6']);
+    assert.equal(line[3].fen, 'r1bqkbnr/pppp1ppp/2n5/4p3/4P3/5N2/PPPP1PPP/RNBQKB1R w KQkq - 2 3');
+  });
+
+  it('replays a tactical line through to mate', () => {
+    // 1. e4 e5 2. Bc4 Nc6 3. Qh5 Nf6?
+    const fen = 'r1bqkb1r/pppp1ppp/2n2n2/4p2Q/2B1P3/8/PPPP1PPP/RNB1K1NR w KQkq - 4 4';
+
+    assert.deepEqual(playUciLine(fen, ['h5f7']).map(move => move.san), ['Qxf7#']);
+  });
+
+  it('stops at the first move that cannot be played', () => {
+    const line = playUciLine(STARTING_FEN, ['e2e4', 'e2e4', 'g1f3']);
+
+    assert.deepEqual(line.map(move => move.uci), ['e2e4']);
+  });
+
+  it('cuts a stale engine line short at its first illegal move', () => {
+    // After 1. e4 e5 the line's e4-e5 is blocked, so the Nf3 that follows it is never reached
+    const line = playUciLine(STARTING_FEN, ['e2e4', 'e7e5', 'e4e5', 'g1f3']);
+
+    assert.deepEqual(line.map(move => move.san), ['e4', 'e5']);
+  });
+
+  it('plays nothing from a line searched for the other side', () => {
+    assert.deepEqual(playUciLine(STARTING_FEN, ['e7e5', 'g1f3']), []);
+  });
+});
+
+describe('ChessGameManager.loadPGN', () => {
+  it('reads the termination tag', () => {
+    const pgn = [
+      '[White "Anna"]',
+      '[Black "Boris"]',
+      '[Result "1-0"]',
+      '[Termination "Anna won by resignation"]',
+      '',
+      '1. e4 e5 2. Qh5 Nc6 3. Bc4 Nf6 4. Qxf7# 1-0'
+    ].join('\n');
+    const game = new ChessGameManager().loadPGN(pgn);
+
+    assert.equal(game.gameInfo.termination, 'Anna won by resignation');
+    assert.equal(game.gameInfo.result, '1-0');
+    assert.equal(game.moves.length, 7);
+  });
+
+  it('rejects unsupported variants before parsing the moves', () => {
+    const atomic = '[Variant "Atomic"]\n\n1. e4 e5 2. Nf3 *';
+
+    assert.throws(() => new ChessGameManager().loadPGN(atomic), {
+      message: 'Unsupported variant: Atomic. Only standard chess games can be analyzed'
+    });
+  });
+
+  it('rejects Chess960, whose castling chess.js cannot play', () => {
+    assert.throws(() => new ChessGameManager().loadPGN('[Variant "Chess960"]\n\n1. e4 *'), /Unsupported variant: Chess960/);
+  });
+
+  it('accepts games explicitly tagged as standard', () => {
+    assert.equal(new ChessGameManager().loadPGN('[Variant "Standard"]\n\n1. e4 e5 *').moves.length, 2);
+  });
+});
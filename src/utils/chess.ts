@@ -1,23 +1,48 @@
 import { Chess } from 'chess.js';
-import { ChessMove, GameInfo, GameState, PieceType, PieceColor } from '@/types/chess';
+import { EngineEvaluation, RepetitionInfo, SuggestedMove } from '@/types/analysis';
+import { ChessMove, GameInfo, GameState, PieceType, PieceColor, VariationMove } from '@/types/chess';
+import { WIN_PERCENTAGE_PRECISION, roundTo, toPlayerPerspective, winPercentage } from '@/utils/stockfish';
+
+export const STARTING_FEN = 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1';
+// Chess960 is not listed: chess.js can't play its castling moves
+const SUPPORTED_VARIANTS = ['standard', 'from position'];
 
 export class ChessGameManager {
   private chess: Chess;
   private moveHistory: ChessMove[] = [];
+  private startingFen = STARTING_FEN;
 
   constructor(pgn?: string, fen?: string) {
     this.chess = new Chess();
     
     if (fen) {
+      const validation = validateFEN(fen);
+      if (!validation.valid) {
+        throw new Error(`Invalid FEN: ${validation.error}`);
+      }
       this.chess.load(fen);
+      this.startingFen = fen;
     } else if (pgn) {
       this.loadPGN(pgn);
     }
   }
 
   loadPGN(pgn: string): GameState {
+    // Check the variant before parsing, since variant moves (e.g. Crazyhouse drops) won't parse
+    const variant = pgn.match(/\[Variant\s+"([^"]*)"\]/i)?.[1];
+    if (variant && !SUPPORTED_VARIANTS.includes(variant.toLowerCase())) {
+      throw new Error(`Unsupported variant: ${variant}. Only standard chess games can be analyzed`);
+    }
+
     try {
       this.chess.loadPgn(pgn);
+      // Games set up from a position carry their starting FEN in the header
+      const startingFen = this.chess.header().FEN || STARTING_FEN;
+      const validation = validateFEN(startingFen);
+      if (!validation.valid) {
+        throw new Error(`Invalid FEN header: ${validation.error}`);
+      }
+      this.startingFen = startingFen;
       this.moveHistory = this.extractMoves();
       
       const gameInfo = this.extractGameInfo(pgn);
@@ -27,7 +52,7 @@ export class ChessGameManager {
         currentMoveIndex: this.moveHistory.length - 1,
         gameInfo,
         pgn,
-        startingFen: 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1'
+        startingFen: this.startingFen
       };
     } catch (error) {
       throw new Error(`Invalid PGN: ${error}`);
@@ -35,11 +60,11 @@ export class ChessGameManager {
   }
 
   private extractMoves(): ChessMove[] {
-    const tempChess = new Chess();
+    const tempChess = new Chess(this.startingFen);
     const moves: ChessMove[] = [];
     const history = this.chess.history({ verbose: true });
 
-    history.forEach((move, index) => {
+    history.forEach((move) => {
       const chessMove: ChessMove = {
         from: move.from,
         to: move.to,
@@ -48,7 +73,7 @@ export class ChessGameManager {
         promotion: move.promotion as PieceType | undefined,
         san: move.san,
         fen: move.after || tempChess.fen(),
-        moveNumber: Math.ceil((index + 1) / 2),
+        moveNumber: tempChess.moveNumber(),
         color: move.color as PieceColor
       };
 
@@ -72,7 +97,8 @@ export class ChessGameManager {
       event: headers.Event,
       site: headers.Site,
       opening: headers.Opening,
-      eco: headers.ECO
+      eco: headers.ECO,
+      termination: headers.Termination
     };
   }
 
@@ -82,7 +108,7 @@ export class ChessGameManager {
     }
 
     if (moveIndex < 0 || moveIndex >= this.moveHistory.length) {
-      return 'rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1';
+      return this.startingFen;
     }
 
     return this.moveHistory[moveIndex].fen;
@@ -154,7 +180,204 @@ export class ChessGameManager {
   reset(): void {
     this.chess.reset();
     this.moveHistory = [];
+    this.startingFen = STARTING_FEN;
+  }
+}
+
+export function validateFEN(fen: string): { valid: boolean; error?: string } {
+  const fields = fen.trim().split(/\s+/);
+  if (fields.length !== 6) {
+    return { valid: false, error: 'FEN must have 6 space-separated fields' };
+  }
+
+  const [placement, activeColor, castling, enPassant, halfmove, fullmove] = fields;
+
+  // Piece placement
+  const ranks = placement.split('/');
+  if (ranks.length !== 8) {
+    return { valid: false, error: 'Piece placement must describe 8 ranks' };
+  }
+
+  const board: (string | null)[][] = [];
+  for (let r = 0; r < 8; r++) {
+    const row: (string | null)[] = [];
+    for (const char of ranks[r]) {
+      if (/[1-8]/.test(char)) {
+        for (let i = 0; i < parseInt(char); i++) row.push(null);
+      } else if (/[prnbqkPRNBQK]/.test(char)) {
+        row.push(char);
+      } else {
+        return { valid: false, error: `Invalid piece character '${char}' in rank ${8 - r}` };
+      }
+    }
+    if (row.length !== 8) {
+      return { valid: false, error: `Rank ${8 - r} does not have 8 squares` };
+    }
+    board.push(row);
+  }
+
+  // board[0] is rank 8, board[7] is rank 1; file a is column 0
+  const pieceAt = (square: string) => board[8 - parseInt(square[1])][square.charCodeAt(0) - 97];
+  const pieces = board.flat().filter((piece): piece is string => piece !== null);
+  const count = (piece: string) => pieces.filter(p => p === piece).length;
+
+  if (count('K') !== 1 || count('k') !== 1) {
+    return { valid: false, error: 'Each side must have exactly one king' };
+  }
+  if (count('P') > 8 || count('p') > 8) {
+    return { valid: false, error: 'A side cannot have more than 8 pawns' };
+  }
+  if (pieces.filter(p => p === p.toUpperCase()).length > 16 || pieces.filter(p => p === p.toLowerCase()).length > 16) {
+    return { valid: false, error: 'A side cannot have more than 16 pieces' };
+  }
+  if ([...board[0], ...board[7]].some(piece => piece === 'P' || piece === 'p')) {
+    return { valid: false, error: 'Pawns cannot stand on the first or last rank' };
+  }
+
+  // Active color
+  if (activeColor !== 'w' && activeColor !== 'b') {
+    return { valid: false, error: `Active color must be 'w' or 'b', got '${activeColor}'` };
+  }
+
+  // Castling rights must match king and rook placement
+  if (castling !== '-') {
+    if (!/^K?Q?k?q?$/.test(castling)) {
+      return { valid: false, error: `Invalid castling rights '${castling}'` };
+    }
+    const requirements: Record<string, [string, string, string, string]> = {
+      K: ['e1', 'K', 'h1', 'R'],
+      Q: ['e1', 'K', 'a1', 'R'],
+      k: ['e8', 'k', 'h8', 'r'],
+      q: ['e8', 'k', 'a8', 'r']
+    };
+    for (const right of castling) {
+      const [kingSquare, king, rookSquare, rook] = requirements[right];
+      if (pieceAt(kingSquare) !== king || pieceAt(rookSquare) !== rook) {
+        return { valid: false, error: `Castling right '${right}' requires a king on ${kingSquare} and a rook on ${rookSquare}` };
+      }
+    }
+  }
+
+  // En passant target must sit behind a pawn that just made a double step
+  if (enPassant !== '-') {
+    if (!isValidSquare(enPassant)) {
+      return { valid: false, error: `Invalid en passant square '${enPassant}'` };
+    }
+    const expectedRank = activeColor === 'w' ? '6' : '3';
+    if (enPassant[1] !== expectedRank) {
+      return { valid: false, error: `En passant square must be on rank ${expectedRank} when ${activeColor === 'w' ? 'White' : 'Black'} is to move` };
+    }
+    const pawnSquare = enPassant[0] + (activeColor === 'w' ? '5' : '4');
+    const pawn = activeColor === 'w' ? 'p' : 'P';
+    if (pieceAt(enPassant) !== null || pieceAt(pawnSquare) !== pawn) {
+      return { valid: false, error: `En passant square ${enPassant} has no pawn that just moved past it` };
+    }
   }
+
+  // Move counters
+  if (!/^\d+$/.test(halfmove)) {
+    return { valid: false, error: 'Halfmove clock must be a non-negative integer' };
+  }
+  if (!/^\d+$/.test(fullmove) || parseInt(fullmove) < 1) {
+    return { valid: false, error: 'Fullmove number must be a positive integer' };
+  }
+
+  return { valid: true };
+}
+
+export function detectRepetitions(startingFen: string, moves: ChessMove[]): RepetitionInfo {
+  // Placement, side to move, castling and en passant identify a position for repetition purposes
+  const positionKey = (fen: string) => fen.split(' ').slice(0, 4).join(' ');
+  const occurrences = new Map<string, number>([[positionKey(startingFen), 1]]);
+  const info: RepetitionInfo = { repeatedMoves: [] };
+  const notes: string[] = [];
+
+  moves.forEach((move, index) => {
+    const key = positionKey(move.fen);
+    const count = (occurrences.get(key) || 0) + 1;
+    occurrences.set(key, count);
+
+    if (count > 1) {
+      info.repeatedMoves.push(index);
+    }
+    if (count === 3 && info.threefoldAt === undefined) {
+      info.threefoldAt = index;
+      notes.push(`Threefold repetition could be claimed after ${move.moveNumber}${move.color === 'w' ? '.' : '...'} ${move.san}`);
+    }
+
+    const halfmoveClock = parseInt(move.fen.split(' ')[4] || '0');
+    if (halfmoveClock >= 100 && info.fiftyMoveAt === undefined) {
+      info.fiftyMoveAt = index;
+      notes.push(`Fifty-move rule could be claimed after ${move.moveNumber}${move.color === 'w' ? '.' : '...'} ${move.san}`);
+    }
+  });
+
+  if (notes.length > 0) {
+    info.description = notes.join('. ');
+  }
+
+  return info;
+}
+
+export function isWhiteToMove(fen: string): boolean {
+  return fen.split(' ')[1] !== 'b';
+}
+
+export function isDeadDraw(fen: string): boolean {
+  // KvK, KBvK, KNvK and same-colored bishops can never produce a checkmate
+  return new Chess(fen).isInsufficientMaterial();
+}
+
+export function playUciLine(fen: string, uciMoves: string[]): VariationMove[] {
+  const chess = new Chess(fen);
+  const line: VariationMove[] = [];
+
+  for (const uci of uciMoves) {
+    try {
+      const move = chess.move({
+        from: uci.slice(0, 2),
+        to: uci.slice(2, 4),
+        promotion: uci.length > 4 ? uci[4] : undefined
+      });
+
+      line.push({
+        uci,
+        san: move.san,
+        fen: chess.fen()
+      });
+    } catch {
+      // Stop at the first move that can't be played from this position
+      break;
+    }
+  }
+
+  return line;
+}
+
+// The engine's move from the position before a played move, with its line in SAN and how many
+// centipawns it would have gained; undefined when the played move lost nothing
+export function buildSuggestedMove(
+  fen: string,
+  positionBefore: EngineEvaluation,
+  positionAfter: EngineEvaluation,
+  isWhiteMove: boolean
+): SuggestedMove | undefined {
+  const { bestMove, principalVariation } = positionBefore;
+  const line = playUciLine(fen, [bestMove, ...principalVariation.slice(1)]);
+  if (line.length === 0) return undefined;
+
+  // Win percentage points for the player who moved, so a pawn matters less once the game is decided
+  const bestWin = winPercentage(toPlayerPerspective(positionBefore.score, isWhiteMove));
+  const playedWin = winPercentage(toPlayerPerspective(positionAfter.score, isWhiteMove));
+  const improvement = roundTo(bestWin - playedWin, WIN_PERCENTAGE_PRECISION);
+  if (improvement <= 0) return undefined;
+
+  return {
+    move: bestMove,
+    san: line[0].san,
+    line: line.map(ply => ply.san),
+    improvement
+  };
 }
 
 export function parseSquareColor(square: string): 'light' | 'dark' {
@@ -0,0 +1,85 @@
+import { afterEach, describe, it } from 'node:test';
+import assert from 'node:assert/strict';
+import { fetchGamePgn, parseGameUrl } from '@/utils/gameImport';
+
+describe('parseGameUrl', () => {
+  const recognized: [string, string, string][] = [
+    ['https://lichess.org/abcdEFGH', 'lichess', 'abcdEFGH'],
+    ['https://www.lichess.org/abcdEFGH', 'lichess', 'abcdEFGH'],
+    ['https://lichess.org/abcdEFGH/black', 'lichess', 'abcdEFGH'],
+    ['https://lichess.org/abcdEFGHijkl', 'lichess', 'abcdEFGH'],
+    ['https://lichess.org/game/export/abcdEFGH', 'lichess', 'abcdEFGH'],
+    ['https://www.chess.com/game/live/123456789', 'chesscom', '123456789'],
+    ['https://www.chess.com/live/game/123456789', 'chesscom', '123456789'],
+    ['https://www.chess.com/game/daily/987654', 'chesscom', '987654']
+  ];
+
+  for (const [url, source, gameId] of recognized) {
+    it(`extracts the game from ${url}`, () => {
+      assert.deepEqual(parseGameUrl(url), { source, gameId });
+    });
+  }
+
+  const rejected = [
+    'not a url',
+    'https://example.com/abcdEFGH',
+    'https://lichess.org/training',
+    'https://lichess.org/settings/board',
+    'https://lichess.org/@/someplayer',
+    'https://lichess.org/abcdEFGH/analysis',
+    'https://www.chess.com/member/someplayer'
+  ];
+
+  for (const url of rejected) {
+    it(`rejects ${url}`, () => {
+      assert.equal(parseGameUrl(url), null);
+    });
+  }
+});
+
+describe('fetchGamePgn', () => {
+  const realFetch = globalThis.fetch;
+
+  afterEach(() => {
+    globalThis.fetch = realFetch;
+  });
+
+  it('fetches the PGN from the Lichess export API', async () => {
+    let requested = '';
+    globalThis.fetch = async (input: RequestInfo | URL) => {
+      requested = String(input);
+      return new Response('1. e4 e5 *', { status: 200 });
+    };
+
+    assert.equal(await fetchGamePgn('https://lichess.org/abcdEFGH/white'), '1. e4 e5 *');
+    assert.match(requested, /^https:\/\/lichess\.org\/game\/export\/abcdEFGH\?/);
+  });
+
+  it('reports a missing game', async () => {
+    globalThis.fetch = async () => new Response('', { status: 404 });
+
+    await assert.rejects(fetchGamePgn('https://lichess.org/abcdEFGH'), { message: 'Lichess game abcdEFGH not found' });
+  });
+
+  it('reports rate limiting', async () => {
+    globalThis.fetch = async () => new Response('', { status: 429 });
+
+    await assert.rejects(fetchGamePgn('https://lichess.org/abcdEFGH'), /rate limit/);
+  });
+
+  it('gives up after the timeout', async () => {
+    globalThis.fetch = (_input: RequestInfo | URL, init?: RequestInit) => new Promise((_resolve, reject) => {
+      init?.signal?.addEventListener('abort', () => reject(new DOMException('Aborted', 'AbortError')));
+    });
+
+    await assert.rejects(fetchGamePgn('https://lichess.org/abcdEFGH', 20), {
+      message: 'Timed out fetching game from Lichess after 0.02s'
+    });
+  });
+
+  it('does not fetch Chess.com games', async () => {
+    globalThis.fetch = async () => assert.fail('fetch should not be called');
+
+    await assert.rejects(fetchGamePgn('https://www.chess.com/game/live/123456789'), /cannot be fetched by URL/);
+  });
+});
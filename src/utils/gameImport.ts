@@ -0,0 +1,101 @@
+export type GameSource = 'lichess' | 'chesscom';
+
+export interface GameUrlInfo {
+  source: GameSource;
+  gameId: string;
+}
+
+const LICHESS_EXPORT_URL = 'https://lichess.org/game/export';
+const DEFAULT_FETCH_TIMEOUT = 10000; // 10 seconds
+
+// Site routes that happen to look like 8 or 12 character game IDs
+const LICHESS_RESERVED_PATHS = new Set([
+  'analysis', 'calendar', 'features', 'insights', 'messages', 'password',
+  'practice', 'settings', 'streamer', 'timeline', 'training', 'tutorial'
+]);
+
+export function parseGameUrl(input: string): GameUrlInfo | null {
+  let url: URL;
+  try {
+    url = new URL(input.trim());
+  } catch {
+    return null;
+  }
+
+  const host = url.hostname.replace(/^www\./, '');
+  const segments = url.pathname.split('/').filter(Boolean);
+
+  if (host === 'lichess.org') {
+    // lichess.org/{id}, lichess.org/{id}/white, lichess.org/{id}/black, lichess.org/{id}{playerId},
+    // lichess.org/game/export/{id}
+    const isExport = segments[0] === 'game' && segments[1] === 'export';
+    const idSegments = isExport ? segments.slice(2) : segments;
+    const [candidate, orientation, ...rest] = idSegments;
+
+    if (!candidate || rest.length > 0) return null;
+    if (orientation !== undefined && (isExport || (orientation !== 'white' && orientation !== 'black'))) return null;
+    if (LICHESS_RESERVED_PATHS.has(candidate.toLowerCase())) return null;
+
+    if (/^[a-zA-Z0-9]{8}([a-zA-Z0-9]{4})?$/.test(candidate)) {
+      return { source: 'lichess', gameId: candidate.slice(0, 8) };
+    }
+    return null;
+  }
+
+  if (host === 'chess.com') {
+    // chess.com/game/live/{id}, chess.com/live/game/{id}, and the daily equivalents
+    const gameId = segments.find(segment => /^\d+$/.test(segment));
+    const isGamePath = segments.includes('game') && (segments.includes('live') || segments.includes('daily'));
+    if (gameId && isGamePath) {
+      return { source: 'chesscom', gameId };
+    }
+    return null;
+  }
+
+  return null;
+}
+
+export function isGameUrl(input: string): boolean {
+  return parseGameUrl(input) !== null;
+}
+
+export async function fetchGamePgn(input: string, timeout = DEFAULT_FETCH_TIMEOUT): Promise<string> {
+  const info = parseGameUrl(input);
+  if (!info) {
+    throw new Error('Unrecognized game URL');
+  }
+
+  if (info.source === 'chesscom') {
+    // Chess.com's public API only exposes games through monthly player archives
+    throw new Error('Chess.com games cannot be fetched by URL yet - please paste the PGN instead');
+  }
+
+  const controller = new AbortController();
+  const timer = setTimeout(() => controller.abort(), timeout);
+
+  try {
+    const response = await fetch(`${LICHESS_EXPORT_URL}/${info.gameId}?clocks=false&evals=false`, {
+      headers: { Accept: 'application/x-chess-pgn' },
+      signal: controller.signal
+    });
+
+    if (response.status === 404) {
+      throw new Error(`Lichess game ${info.gameId} not found`);
+    }
+    if (response.status === 429) {
+      throw new Error('Lichess rate limit reached - please wait a minute and try again');
+    }
+    if (!response.ok) {
+      throw new Error(`Failed to fetch game from Lichess (HTTP ${response.status})`);
+    }
+
+    return await response.text();
+  } catch (error) {
+    if (error instanceof Error && error.name === 'AbortError') {
+      throw new Error(`Timed out fetching game from Lichess after ${timeout / 1000}s`);
+    }
+    throw error;
+  } finally {
+    clearTimeout(timer);
+  }
+}
@@ -0,0 +1,57 @@
+import { describe, it } from 'node:test';
+import assert from 'node:assert/strict';
+import { EngineEvaluation } from '@/types/analysis';
+import { ChessMove } from '@/types/chess';
+import { STARTING_FEN, playUciLine } from '@/utils/chess';
+import { analyzeMoves } from '@/utils/moveAnalysis';
+import { StockfishEngine } from '@/utils/stockfish';
+
+function evaluation(score: number, bestMove: string): EngineEvaluation {
+  return { score, depth: 20, bestMove, principalVariation: [bestMove], nodes: 1000000, time: 100 };
+}
+
+// 1. e4 e5 2. Nf3 Nc6
+const line = playUciLine(STARTING_FEN, ['e2e4', 'e7e5', 'g1f3', 'b8c6']);
+const moves = line.map((ply, i): ChessMove => ({
+  from: ply.uci.slice(0, 2),
+  to: ply.uci.slice(2, 4),
+  piece: i === 2 || i === 3 ? 'n' : 'p',
+  san: ply.san,
+  fen: ply.fen,
+  moveNumber: Math.floor(i / 2) + 1,
+  color: i % 2 === 0 ? 'w' : 'b'
+}));
+const positions = [STARTING_FEN, ...line.map(ply => ply.fen)];
+const evaluations = [
+  evaluation(30, 'e2e4'),
+  evaluation(30, 'e7e5'),
+  evaluation(30, 'g1f3'),
+  evaluation(30, 'b8c6'),
+  evaluation(30, 'f1b5')
+];
+
+describe('analyzeMoves', () => {
+  it('analyzes every move when every position was evaluated', async () => {
+    const analyses = await analyzeMoves(new StockfishEngine(), moves, positions, evaluations);
+
+    assert.deepEqual(analyses.map(analysis => analysis.san), ['e4', 'e5', 'Nf3', 'Nc6']);
+  });
+
+  it('stops at the last evaluated position and keeps moves, analyses and evaluations aligned', async () => {
+    // Analysis stopped after the position following 1... e5
+    const partial = evaluations.slice(0, 3);
+    const analyses = await analyzeMoves(new StockfishEngine(), moves, positions, partial);
+
+    assert.equal(analyses.length, partial.length - 1);
+    analyses.forEach((analysis, i) => {
+      assert.equal(analysis.san, moves[i].san);
+      assert.equal(analysis.move, moves[i].from + moves[i].to);
+      assert.equal(analysis.evaluation, partial[i + 1]);
+      assert.equal(analysis.alternativeMoves?.[0].evaluation, partial[i]);
+    });
+  });
+
+  it('analyzes nothing without an evaluation after the first move', async () => {
+    assert.deepEqual(await analyzeMoves(new StockfishEngine(), moves, positions, evaluations.slice(0, 1)), []);
+  });
+});
@@ -0,0 +1,96 @@
+import { EngineEvaluation, MoveAnalysis } from '@/types/analysis';
+import { ChessMove } from '@/types/chess';
+import { buildSuggestedMove } from '@/utils/chess';
+import {
+  LOW_CONFIDENCE_THRESHOLD,
+  MISSED_MATE_RULE,
+  StockfishEngine,
+  getEvaluationConfidence
+} from '@/utils/stockfish';
+
+// Classifies each move of a game from the evaluations of the positions around it.
+// positions[i] and evaluations[i] describe the position before moves[i]. Evaluations may stop
+// early (aborted or failed analysis); only moves with both positions evaluated are analyzed, so
+// the returned analyses stay aligned by index with moves and evaluations.
+export async function analyzeMoves(
+  engine: StockfishEngine,
+  moves: ChessMove[],
+  positions: string[],
+  evaluations: EngineEvaluation[],
+  debug = false
+): Promise<MoveAnalysis[]> {
+  const moveAnalyses: MoveAnalysis[] = [];
+
+  const analyzedMoveCount = Math.min(moves.length, evaluations.length - 1);
+  for (let i = 0; i < analyzedMoveCount; i++) {
+    const move = moves[i];
+    // UCI, including the promotion piece so promotions can match the engine's best move
+    const playedMove = move.from + move.to + (move.promotion ?? '');
+    const positionBefore = evaluations[i];
+    const positionAfter = evaluations[i + 1];
+
+    const bestMove = positionBefore.bestMove;
+    const classified = engine.classifyMoveWithTrace(
+      positionBefore,
+      positionAfter,
+      playedMove,
+      bestMove,
+      move.color === 'w'
+    );
+    let { classification } = classified;
+    const trace = debug ? classified.trace : undefined;
+
+    // A shallow search can miss a refutation, so brilliant sacrifices must hold up at higher depth
+    if (classification === 'brilliant') {
+      const isSound = await engine.confirmBrilliantMove(
+        positions[i + 1],
+        positionAfter,
+        move.color === 'w'
+      );
+      if (!isSound) {
+        classification = 'best';
+        trace?.notes.push('Brilliant not confirmed by a deeper search, downgraded to best');
+      }
+    }
+
+    const moveAnalysis: MoveAnalysis = {
+      move: playedMove,
+      san: move.san,
+      evaluation: positionAfter,
+      classification,
+      alternativeMoves: [{
+        move: bestMove,
+        evaluation: positionBefore
+      }]
+    };
+
+    const confidence = Math.min(
+      positionBefore.confidence ?? getEvaluationConfidence(positionBefore),
+      positionAfter.confidence ?? getEvaluationConfidence(positionAfter)
+    );
+    if (confidence < LOW_CONFIDENCE_THRESHOLD) {
+      moveAnalysis.provisional = true;
+      trace?.notes.push(`Evaluation confidence ${confidence} is below ${LOW_CONFIDENCE_THRESHOLD}`);
+    }
+    if (trace) {
+      moveAnalysis.trace = trace;
+    }
+
+    if (!['brilliant', 'great', 'best'].includes(classification)) {
+      moveAnalysis.suggestedMove = buildSuggestedMove(
+        positions[i],
+        positionBefore,
+        positionAfter,
+        move.color === 'w'
+      );
+    }
+
+    if (classified.trace.rule === MISSED_MATE_RULE) {
+      moveAnalysis.comment = `Missed forced mate in ${Math.abs(positionBefore.mate!)}`;
+    }
+
+    moveAnalyses.push(moveAnalysis);
+  }
+
+  return moveAnalyses;
+}
@@ -0,0 +1,731 @@
+import { describe, it } from 'node:test';
+import assert from 'node:assert/strict';
+import { EngineEvaluation, MoveAnalysis } from '@/types/analysis';
+import { ChessMove } from '@/types/chess';
+import {
+  CLASSIFICATION_DISPLAY,
+  DEFAULT_STOCKFISH_CONFIG,
+  DEPTH_PRECISION,
+  MATE_SCORE,
+  MOVE_CLASSIFICATIONS,
+  MISSED_MATE_RULE,
+  StockfishEngine,
+  applyConfigDefaults,
+  assessPosition,
+  calculateEngineStats,
+  convertScoreToString,
+  getEvaluationConfidence,
+  getScoreColor,
+  roundTo,
+  toPlayerPerspective
+} from '@/utils/stockfish';
+
+// Engine output for a position; score is White-relative centipawns
+function evaluation(score: number, extra: Partial<EngineEvaluation> = {}): EngineEvaluation {
+  return { score, depth: 20, bestMove: 'e2e4', principalVariation: [], nodes: 1000000, time: 100, ...extra };
+}
+
+// Placeholder moves for a game of the given length; only color and move number matter to the detectors
+function plies(count: number, whiteMovesFirst = true): ChessMove[] {
+  return Array.from({ length: count }, (_, i): ChessMove => {
+    const isWhite = (i % 2 === 0) === whiteMovesFirst;
+    return {
+      from: 'a1',
+      to: 'a2',
+      piece: 'k',
+      san: 'Ka2',
+      fen: '',
+      moveNumber: Math.floor((i + (whiteMovesFirst ? 0 : 1)) / 2) + 1,
+      color: isWhite ? 'w' : 'b'
+    };
+  });
+}
+
+// The mock engine only becomes ready in a browser, and nothing tested here reaches its random search
+function readyEngine(): StockfishEngine {
+  const engine = new StockfishEngine();
+  engine['isReady'] = true;
+  return engine;
+}
+
+describe('detectEndgameVerdict', () => {
+  it('reports the move where a winning endgame was let slip to a draw', () => {
+    const engine = new StockfishEngine();
+    const moves = plies(40);
+    // White is +6 until its 18th move (ply 35) throws the win away
+    const evaluations = Array.from({ length: 41 }, (_, i) => evaluation(i < 35 ? 600 : 0));
+
+    assert.deepEqual(engine.detectEndgameVerdict(evaluations, moves, 30), {
+      moveNumber: 18,
+      player: 'white',
+      from: 'win',
+      to: 'draw',
+      description: 'Winning endgame converted to draw at move 18'
+    });
+  });
+
+  it('ignores swings before the endgame starts', () => {
+    const engine = new StockfishEngine();
+    const moves = plies(40);
+    const evaluations = Array.from({ length: 41 }, (_, i) => evaluation(i < 11 ? 600 : 0));
+
+    assert.equal(engine.detectEndgameVerdict(evaluations, moves, 30), undefined);
+  });
+});
+
+describe('missed mate classification', () => {
+  const mateInTwo = evaluation(MATE_SCORE, { mate: 2, bestMove: 'd1h5' });
+
+  it('flags a non-mating capture when a mate in 2 was available', () => {
+    const engine = new StockfishEngine();
+    const { classification, trace } = engine.classifyMoveWithTrace(mateInTwo, evaluation(300), 'd1d7', 'd1h5', true);
+
+    assert.equal(classification, 'miss');
+    assert.equal(trace.rule, MISSED_MATE_RULE);
+  });
+
+  it('flags Black missing a mate from its own perspective', () => {
+    const engine = new StockfishEngine();
+    const before = evaluation(-MATE_SCORE, { mate: -2, bestMove: 'd8h4' });
+
+    assert.equal(engine.classifyMove(before, evaluation(-300), 'd8d2', 'd8h4', false), 'miss');
+  });
+
+  it('does not flag the best move keeping the mate', () => {
+    const engine = new StockfishEngine();
+    const { trace } = engine.classifyMoveWithTrace(mateInTwo, evaluation(MATE_SCORE, { mate: 1 }), 'd1h5', 'd1h5', true);
+
+    assert.notEqual(trace.rule, MISSED_MATE_RULE);
+  });
+
+  it('does not flag a different move that mates at once', () => {
+    const engine = new StockfishEngine();
+    // Black is checkmated after the move, so the engine reports mate 0 for the side to move
+    const mated = evaluation(MATE_SCORE, { mate: 0 });
+    const { trace } = engine.classifyMoveWithTrace(mateInTwo, mated, 'd1d8', 'd1h5', true);
+
+    assert.notEqual(trace.rule, MISSED_MATE_RULE);
+  });
+});
+
+describe('games starting with Black to move', () => {
+  // Black moves on odd plies and White on even ones; White gives away 5 pawns on its first move
+  const evaluations = [0, 0, -500, -500, -500].map(score => evaluation(score));
+
+  it('scores each player on their own moves', () => {
+    const engine = new StockfishEngine();
+
+    assert.equal(engine.calculateAccuracy(evaluations, true, false), 75);
+    assert.equal(engine.calculateAccuracy(evaluations, false, false), 100);
+  });
+
+  it('takes move numbers and colors from the moves', () => {
+    const engine = new StockfishEngine();
+    const moment = engine.detectDecisiveMoment(evaluations, plies(4, false));
+
+    assert.equal(moment?.moveNumber, 2);
+    assert.equal(moment?.advantage, 'black');
+  });
+});
+
+describe('accuracy skipping dead-drawn positions', () => {
+  it('leaves out moves played from a flagged position', () => {
+    const engine = new StockfishEngine();
+    // White's second move (ply 3) loses 3 pawns, but it starts from a dead draw
+    const evaluations = [0, 0, 0, -300, -300].map(score => evaluation(score));
+    const deadDraws = [false, false, true, true, true];
+
+    assert.equal(engine.calculateAccuracy(evaluations, true), 85);
+    assert.equal(engine.calculateAccuracy(evaluations, true, true, deadDraws), 100);
+  });
+
+  it('gives no accuracy when every move starts from a dead draw', () => {
+    const engine = new StockfishEngine();
+    const evaluations = [0, 0, 0].map(score => evaluation(score));
+
+    assert.equal(engine.calculateAccuracy(evaluations, true, true, [true, true, true]), undefined);
+    assert.equal(engine.calculateAccuracy(evaluations, false, true, [true, true, true]), undefined);
+  });
+
+  it('leaves the same moves out of the phase accuracies', () => {
+    const engine = new StockfishEngine();
+    // The endgame starts at ply 30; White's ply 35 throws away 3 pawns from a dead draw reached at ply 34
+    const evaluations = Array.from({ length: 41 }, (_, i) => evaluation(i >= 35 ? -300 : 0));
+    const deadDraws = evaluations.map((_, i) => i >= 34);
+
+    const unskipped = engine.analyzeGamePhases(plies(40), evaluations).endgameAccuracy;
+    assert.ok(unskipped !== undefined && unskipped < 100, `expected ${unskipped} below 100`);
+    assert.equal(engine.analyzeGamePhases(plies(40), evaluations, true, deadDraws).endgameAccuracy, 100);
+  });
+
+  it('gives no phase accuracy when the whole phase is a dead draw', () => {
+    const engine = new StockfishEngine();
+    const evaluations = Array.from({ length: 41 }, () => evaluation(0));
+    const phases = engine.analyzeGamePhases(plies(40), evaluations, true, evaluations.map((_, i) => i >= 30));
+
+    assert.equal(phases.endgameAccuracy, undefined);
+    assert.equal(phases.middlegameAccuracy, 100);
+  });
+});
+
+describe('checkResultConsistency', () => {
+  it('flags Black winning from a final position of +4 for White', () => {
+    const engine = new StockfishEngine();
+    const consistency = engine.checkResultConsistency([0, 150, 400].map(score => evaluation(score)), '0-1');
+
+    assert.equal(consistency.isConsistent, false);
+    assert.equal(consistency.finalEvaluation, 400);
+    assert.match(consistency.note ?? '', /^Black won despite a losing final position/);
+  });
+
+  it('accepts a result that matches the final evaluation', () => {
+    const engine = new StockfishEngine();
+    const consistency = engine.checkResultConsistency([0, 150, 400].map(score => evaluation(score)), '1-0');
+
+    assert.deepEqual(consistency, { isConsistent: true, finalEvaluation: 400, note: undefined });
+  });
+
+  it('explains a time forfeit from a winning position instead of flagging it', () => {
+    const engine = new StockfishEngine();
+    const consistency = engine.checkResultConsistency([0, 150, 400].map(score => evaluation(score)), '0-1', 'Time forfeit');
+
+    assert.deepEqual(consistency, { isConsistent: true, finalEvaluation: 400, note: 'White lost on time in a winning position' });
+  });
+
+  it('explains a resignation from a winning position instead of flagging it', () => {
+    const engine = new StockfishEngine();
+    const consistency = engine.checkResultConsistency(
+      [0, -150, -400].map(score => evaluation(score)),
+      '1-0',
+      'Anna won by resignation'
+    );
+
+    assert.deepEqual(consistency, { isConsistent: true, finalEvaluation: -400, note: 'Black resigned in a winning position' });
+  });
+
+  it('still flags the contradiction when the game ended normally', () => {
+    const engine = new StockfishEngine();
+    const consistency = engine.checkResultConsistency([0, 150, 400].map(score => evaluation(score)), '0-1', 'Normal');
+
+    assert.equal(consistency.isConsistent, false);
+  });
+});
+
+describe('accuracy models', () => {
+  // White gives away 1 pawn, then 3 more; Black loses nothing
+  const evaluations = [0, -100, -100, -400, -400].map(score => evaluation(score));
+
+  it('scores chess.com style as 100 minus a tenth of the average centipawn loss', () => {
+    const engine = new StockfishEngine({ accuracyModel: 'chesscom' });
+
+    // Average loss 200cp
+    assert.equal(engine.calculateAccuracy(evaluations, true), 80);
+    assert.equal(engine.calculateAccuracy(evaluations, false), 100);
+  });
+
+  it('scores lichess style as the average of per-move accuracies from win percentage lost', () => {
+    const engine = new StockfishEngine({ accuracyModel: 'lichess' });
+
+    // About 9.1 and 22.2 win percentage points lost, rated 66.3 and 36.0
+    assert.equal(engine.calculateAccuracy(evaluations, true), 51.1);
+    assert.equal(engine.calculateAccuracy(evaluations, false), 100);
+  });
+
+  it('classifies with each model\'s own thresholds', () => {
+    // Dropping from +6 to +5.4 costs 60cp but barely 2 win percentage points
+    const before = evaluation(600);
+    const after = evaluation(540);
+
+    assert.equal(new StockfishEngine({ accuracyModel: 'chesscom' }).classifyMove(before, after, 'a1a2', 'e2e4'), 'inaccuracy');
+    assert.equal(new StockfishEngine({ accuracyModel: 'lichess' }).classifyMove(before, after, 'a1a2', 'e2e4'), 'good');
+  });
+});
+
+describe('terminal positions', () => {
+  it('returns a decisive mate score for checkmate without a best move', async () => {
+    // Fool's mate: White to move is checkmated
+    const fen = 'rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3';
+    const result = await readyEngine().analyzePosition(fen);
+
+    assert.equal(result.score, -MATE_SCORE);
+    assert.equal(result.mate, 0);
+    assert.equal(result.bestMove, '');
+    assert.deepEqual(result.principalVariation, []);
+  });
+
+  it('returns an equal score for stalemate without a best move', async () => {
+    const fen = '7k/5Q2/6K1/8/8/8/8/8 b - - 0 1';
+    const result = await readyEngine().analyzePosition(fen);
+
+    assert.equal(result.score, 0);
+    assert.equal(result.mate, undefined);
+    assert.equal(result.bestMove, '');
+    assert.deepEqual(result.principalVariation, []);
+  });
+
+  it('shows a delivered checkmate as the result rather than mate in 0', () => {
+    assert.equal(convertScoreToString(MATE_SCORE, 0), '1-0');
+    assert.equal(convertScoreToString(-MATE_SCORE, 0), '0-1');
+    assert.equal(convertScoreToString(MATE_SCORE, 3), 'M3');
+  });
+
+  it('reports a mating move as checkmate without mate patterns', () => {
+    const engine = new StockfishEngine();
+    const tactics = engine.analyzeTacticalPatterns(evaluation(MATE_SCORE, { mate: 1 }), evaluation(MATE_SCORE, { mate: 0 }), 'h5f7');
+
+    assert.equal(tactics.description, 'Checkmate');
+    assert.deepEqual(tactics.patterns, ['none']);
+  });
+});
+
+describe('detectDecisiveMoment', () => {
+  it('picks the permanent swing over larger-looking reversible ones', () => {
+    const engine = new StockfishEngine();
+    // Swings at plies 1 and 3 are undone right away; Black's blunder at ply 6 never is
+    const scores = [0, 300, 0, -250, 0, 0, 700, 650, 700, 650];
+    const moment = engine.detectDecisiveMoment(scores.map(score => evaluation(score)), plies(9));
+
+    assert.equal(moment?.moveNumber, 3);
+    assert.equal(moment?.advantage, 'white');
+    assert.equal(moment?.beforeEval, 0);
+    assert.equal(moment?.afterEval, 700);
+    assert.match(moment?.description ?? '', /winning chances rose from 50\.0% to 92\.9% and never came back$/);
+  });
+
+  it('finds nothing when no swing is large enough to decide the game', () => {
+    const engine = new StockfishEngine();
+    const scores = [0, 100, 0, -100, 0, 50];
+
+    assert.equal(engine.detectDecisiveMoment(scores.map(score => evaluation(score)), plies(5)), undefined);
+  });
+});
+
+describe('detectMissedOpportunities', () => {
+  it('flags a reply that failed to punish the opponent\'s blunder', () => {
+    const engine = new StockfishEngine();
+    // Black blunders on move 1, White's second move gives the win straight back
+    const scores = [0, 0, 600, 50, 50];
+    const opportunities = engine.detectMissedOpportunities(scores.map(score => evaluation(score)), plies(4));
+
+    assert.equal(opportunities.length, 1);
+    assert.equal(opportunities[0].moveNumber, 2);
+    assert.equal(opportunities[0].advantage, 'white');
+    assert.equal(opportunities[0].beforeEval, 600);
+    assert.equal(opportunities[0].afterEval, 50);
+  });
+
+  it('does not flag a reply that kept the win', () => {
+    const engine = new StockfishEngine();
+    const scores = [0, 0, 600, 620, 600];
+
+    assert.deepEqual(engine.detectMissedOpportunities(scores.map(score => evaluation(score)), plies(4)), []);
+  });
+});
+
+describe('decided position weighting', () => {
+  // White blunders into a lost position on its third move...
+  const game = [0, 0, -100, -100, -100, -900];
+  // ...then throws away another 6 pawns twice once nothing is left to play for
+  const sloppyFinish = [...game, -900, -1500, -1500, -2100];
+
+  it('barely moves the accuracy for sloppy moves in a lost position', () => {
+    const engine = new StockfishEngine();
+
+    assert.equal(engine.calculateAccuracy(game.map(score => evaluation(score)), true), 73.3);
+    assert.equal(engine.calculateAccuracy(sloppyFinish.map(score => evaluation(score)), true), 69.4);
+  });
+
+  it('counts every move fully with a weight of 1', () => {
+    const engine = new StockfishEngine({ decidedPositionWeight: 1 });
+
+    assert.equal(engine.calculateAccuracy(sloppyFinish.map(score => evaluation(score)), true), 60);
+  });
+
+  it('falls back to an unweighted average when a zero weight leaves nothing to count', () => {
+    const engine = new StockfishEngine({ decidedPositionWeight: 0 });
+    const lost = [-900, -1000, -1000, -1100, -1100];
+
+    assert.equal(engine.calculateAccuracy(lost.map(score => evaluation(score)), true), 90);
+  });
+});
+
+describe('analysis limits', () => {
+  it('rejects a configured depth above the limit', () => {
+    assert.throws(() => new StockfishEngine({ depth: 40 }), { message: 'Analysis depth must be between 1 and 30, got 40' });
+  });
+
+  it('rejects a configured time above the limit', () => {
+    assert.throws(() => new StockfishEngine({ time: 60000 }), {
+      message: 'Analysis time must be between 1 and 30000ms, got 60000ms'
+    });
+  });
+
+  it('rejects an over-limit depth for a single position', async () => {
+    await assert.rejects(readyEngine().analyzePosition('8/8/4k3/8/8/3K4/8/8 w - - 0 1', 31), {
+      message: 'Analysis depth must be between 1 and 30, got 31'
+    });
+  });
+});
+
+describe('opening book depth', () => {
+  const moves = plies(80);
+  const evaluations = Array.from({ length: 81 }, () => evaluation(0));
+
+  it('ends the opening at the default book depth', () => {
+    assert.equal(new StockfishEngine().analyzeGamePhases(moves, evaluations).opening, 15);
+  });
+
+  it('ends the opening at a configured book depth', () => {
+    assert.equal(new StockfishEngine({ openingBookDepth: 8 }).analyzeGamePhases(moves, evaluations).opening, 8);
+  });
+
+  it('shortens the opening for short games', () => {
+    assert.equal(new StockfishEngine().analyzeGamePhases(plies(20), evaluations.slice(0, 21)).opening, 5);
+  });
+});
+
+describe('confirmBrilliantMove', () => {
+  // After the sacrifice the shallow search thinks White is +4
+  const shallow = evaluation(400, { depth: 12 });
+  const fenAfter = 'r1bqkb1r/pppp1Bpp/2n2n2/4p3/4P3/8/PPPP1PPP/RNBQK1NR b KQkq - 0 4';
+
+  it('rejects a sacrifice that a deeper search refutes', async () => {
+    const engine = new StockfishEngine();
+    let searchedDepth: number | undefined;
+    engine.analyzePosition = async (_fen, depth) => {
+      searchedDepth = depth;
+      return evaluation(-200);
+    };
+
+    assert.equal(await engine.confirmBrilliantMove(fenAfter, shallow, true), false);
+    assert.equal(searchedDepth, 18);
+  });
+
+  it('confirms a sacrifice that holds up at depth', async () => {
+    const engine = new StockfishEngine();
+    engine.analyzePosition = async () => evaluation(350);
+
+    assert.equal(await engine.confirmBrilliantMove(fenAfter, shallow, true), true);
+  });
+});
+
+describe('position assessment', () => {
+  it('uses the shared thresholds for both sides alike', () => {
+    const cases: [number, string][] = [[0, 'equal'], [49, 'equal'], [50, 'slight_advantage'], [150, 'advantage'], [300, 'winning']];
+    for (const [score, assessment] of cases) {
+      assert.equal(assessPosition(score), assessment);
+      assert.equal(assessPosition(-score), assessment);
+    }
+  });
+
+  it('colors scores from the same assessment', () => {
+    assert.equal(getScoreColor(40), 'text-gray-600');
+    assert.equal(getScoreColor(-40), 'text-gray-600');
+    assert.equal(getScoreColor(60), 'text-green-600');
+    assert.equal(getScoreColor(-60), 'text-red-600');
+  });
+});
+
+describe('validateGameLength', () => {
+  it('rejects a 500-move game', () => {
+    assert.throws(() => new StockfishEngine().validateGameLength(1000), {
+      message: 'Game is too long to analyze (500 moves, limit is 300)'
+    });
+  });
+
+  it('accepts a game at the limit', () => {
+    assert.doesNotThrow(() => new StockfishEngine().validateGameLength(599));
+  });
+
+  it('uses the configured limit', () => {
+    assert.throws(() => new StockfishEngine({ maxAnalysisMoves: 40 }).validateGameLength(81), /41 moves, limit is 40/);
+  });
+});
+
+describe('getEvaluationConfidence', () => {
+  it('trusts a deep search more than a shallow one of the same position', () => {
+    const shallow = getEvaluationConfidence(evaluation(35, { depth: 8, nodes: 20000 }));
+    const deep = getEvaluationConfidence(evaluation(35, { depth: 22, nodes: 5000000 }));
+
+    assert.ok(shallow < deep, `expected ${shallow} < ${deep}`);
+    assert.ok(deep <= 1);
+  });
+});
+
+describe('calculateEngineStats', () => {
+  it('sums the per-position node counts and times', () => {
+    const evaluations = [
+      evaluation(0, { nodes: 120000, time: 310.4, depth: 18 }),
+      evaluation(20, { nodes: 80000, time: 250.3, depth: 20 }),
+      evaluation(-15, { nodes: 200000, time: 402.1, depth: 21 })
+    ];
+
+    assert.deepEqual(calculateEngineStats(evaluations), {
+      totalNodes: 400000,
+      totalTime: 963,
+      averageDepth: 19.7,
+      positionsAnalyzed: 3
+    });
+  });
+
+  it('averages depth to the shared depth precision', () => {
+    const stats = calculateEngineStats([18, 20, 20].map(depth => evaluation(0, { depth })));
+
+    assert.equal(stats.averageDepth, roundTo(58 / 3, DEPTH_PRECISION));
+  });
+});
+
+describe('output precision', () => {
+  it('rounds to the requested number of decimals', () => {
+    assert.equal(roundTo(73.333333, 1), 73.3);
+    assert.equal(roundTo(0.666, 2), 0.67);
+  });
+
+  it('reports accuracy with at most one decimal place', () => {
+    const hasOneDecimal = (value: number) => Math.abs(value * 10 - Math.round(value * 10)) < 1e-9;
+    const evaluations = [0, -37, 12, -123, -61, -333, -290].map(score => evaluation(score));
+
+    for (const accuracyModel of ['chesscom', 'lichess'] as const) {
+      const engine = new StockfishEngine({ accuracyModel });
+      for (const isWhite of [true, false]) {
+        const accuracy = engine.calculateAccuracy(evaluations, isWhite);
+        assert.ok(accuracy !== undefined && hasOneDecimal(accuracy), `${accuracyModel} accuracy ${accuracy} has more than one decimal`);
+      }
+    }
+  });
+});
+
+describe('classification display', () => {
+  it('lists every classification from best to worst', () => {
+    assert.deepEqual(MOVE_CLASSIFICATIONS, ['brilliant', 'great', 'best', 'good', 'inaccuracy', 'mistake', 'blunder', 'miss']);
+  });
+
+  it('gives every classification a label, symbol and colors', () => {
+    for (const classification of MOVE_CLASSIFICATIONS) {
+      const { label, symbol, badgeColor, dotColor } = CLASSIFICATION_DISPLAY[classification];
+      assert.ok(label && symbol && badgeColor && dotColor, `${classification} is missing display fields`);
+    }
+  });
+});
+
+describe('White-relative scores', () => {
+  it('flips only for Black', () => {
+    assert.equal(toPlayerPerspective(120, true), 120);
+    assert.equal(toPlayerPerspective(120, false), -120);
+  });
+
+  it('classifies the same error the same way for either color', () => {
+    const engine = new StockfishEngine();
+
+    // White drops from +0.5 to -2; Black makes the mirror-image error
+    assert.equal(engine.classifyMove(evaluation(50), evaluation(-200), 'a1a2', 'e2e4', true), 'mistake');
+    assert.equal(engine.classifyMove(evaluation(-50), evaluation(200), 'a1a2', 'e2e4', false), 'mistake');
+  });
+
+  it('swaps the accuracies when the game is mirrored', () => {
+    const engine = new StockfishEngine();
+    const scores = [0, -40, 20, -180, -150, -420];
+    const game = scores.map(score => evaluation(score));
+    const mirrored = scores.map(score => evaluation(-score));
+
+    assert.equal(engine.calculateAccuracy(game, true), engine.calculateAccuracy(mirrored, false, false));
+    assert.equal(engine.calculateAccuracy(game, false), engine.calculateAccuracy(mirrored, true, false));
+  });
+});
+
+describe('calculateGameQuality', () => {
+  it('scores a clean, sharp game above a sloppy one-sided rout', () => {
+    const engine = new StockfishEngine();
+    const sharp = [0, 30, -20, 40, -220, 10, 250, 0, 20, -10].map(score => evaluation(score));
+    const rout = [0, 20, 450, 700, 900, 1000, 1000, 1000, 1000, 1000].map(score => evaluation(score));
+
+    const sharpQuality = engine.calculateGameQuality(92, 90, sharp, engine.detectCriticalMoments(sharp));
+    const routQuality = engine.calculateGameQuality(88, 41, rout, engine.detectCriticalMoments(rout));
+
+    assert.ok(
+      sharpQuality !== undefined && routQuality !== undefined && sharpQuality > routQuality,
+      `expected ${sharpQuality} > ${routQuality}`
+    );
+    assert.ok(sharpQuality <= 100 && routQuality >= 0);
+  });
+
+  it('scores an empty game as 0', () => {
+    assert.equal(new StockfishEngine().calculateGameQuality(0, 0, [], []), 0);
+  });
+
+  it('averages only the accuracies that could be scored', () => {
+    const engine = new StockfishEngine();
+    const evaluations = [0, 0, 0].map(score => evaluation(score));
+
+    assert.equal(engine.calculateGameQuality(90, undefined, evaluations, []), engine.calculateGameQuality(90, 90, evaluations, []));
+    assert.equal(engine.calculateGameQuality(undefined, undefined, evaluations, []), undefined);
+  });
+});
+
+describe('applyConfigDefaults', () => {
+  it('gives the documented defaults for missing or empty options', () => {
+    assert.deepEqual(applyConfigDefaults(), DEFAULT_STOCKFISH_CONFIG);
+    assert.deepEqual(applyConfigDefaults({}), DEFAULT_STOCKFISH_CONFIG);
+    assert.deepEqual(DEFAULT_STOCKFISH_CONFIG, {
+      depth: 15,
+      time: 1000,
+      threads: 1,
+      hash: 128,
+      maxDepth: 30,
+      maxTime: 30000,
+      maxAnalysisMoves: 300,
+      openingBookDepth: 15,
+      accuracyModel: 'chesscom',
+      criticalMomentThreshold: 200,
+      decidedPositionWeight: 0.2
+    });
+  });
+
+  it('keeps defaults for explicitly undefined options', () => {
+    assert.equal(applyConfigDefaults({ depth: undefined }).depth, 15);
+  });
+
+  it('overrides only the options given', () => {
+    assert.deepEqual(applyConfigDefaults({ depth: 20, accuracyModel: 'lichess' }), {
+      ...DEFAULT_STOCKFISH_CONFIG,
+      depth: 20,
+      accuracyModel: 'lichess'
+    });
+  });
+
+  it('does not share state between calls', () => {
+    applyConfigDefaults().depth = 5;
+    assert.equal(applyConfigDefaults().depth, 15);
+  });
+});
+
+describe('critical moment threshold', () => {
+  // Swings of 250, 300 and 400 centipawns at plies 1, 3 and 5
+  const evaluations = [0, 250, 200, 500, 450, 850].map(score => evaluation(score));
+
+  it('reports every swing above the default threshold', () => {
+    assert.deepEqual(new StockfishEngine().detectCriticalMoments(evaluations), [1, 3, 5]);
+  });
+
+  it('reports fewer moments with a higher threshold', () => {
+    assert.deepEqual(new StockfishEngine({ criticalMomentThreshold: 350 }).detectCriticalMoments(evaluations), [5]);
+  });
+});
+
+describe('detectOpeningTrap', () => {
+  // Legal's mate: 1. e4 e5 2. Nf3 d6 3. Bc4 Bg4 4. Nc3 g6 5. Nxe5 Bxd1 6. Bxf7+ Ke7 7. Nd5#
+  const line: [string, string, string][] = [
+    ['e4', 'e2', 'e4'], ['e5', 'e7', 'e5'], ['Nf3', 'g1', 'f3'], ['d6', 'd7', 'd6'], ['Bc4', 'f1', 'c4'], ['Bg4', 'c8', 'g4'],
+    ['Nc3', 'b1', 'c3'], ['g6', 'g7', 'g6'], ['Nxe5', 'f3', 'e5'], ['Bxd1', 'g4', 'd1'], ['Bxf7+', 'c4', 'f7'], ['Ke7', 'e8', 'e7'],
+    ['Nd5#', 'c3', 'd5']
+  ];
+  const moves = plies(line.length).map((move, i): ChessMove => {
+    const [san, from, to] = line[i];
+    return { ...move, san, from, to };
+  });
+  const analyses = (blunderAt: number) => moves.map((move, i): MoveAnalysis => ({
+    move: move.from + move.to,
+    san: move.san,
+    evaluation: evaluation(0),
+    classification: i === blunderAt ? 'blunder' : 'good'
+  }));
+  // The engine's best reply after 5...Bxd1 is 6. Bxf7+
+  const evaluations = moves.map((_, i) => evaluation(0, { bestMove: i === 10 ? 'c4f7' : '' }));
+
+  it('flags the move that walked into the trap', () => {
+    const engine = new StockfishEngine();
+
+    assert.deepEqual(engine.detectOpeningTrap(analyses(9), moves, evaluations), {
+      moveNumber: 5,
+      player: 'black',
+      move: 'Bxd1',
+      refutation: 'Bxf7+',
+      description: 'Black fell into an opening trap with 5... Bxd1, refuted by Bxf7+'
+    });
+  });
+
+  it('ignores a blunder the opponent did not punish with the forcing reply', () => {
+    const engine = new StockfishEngine();
+
+    assert.equal(engine.detectOpeningTrap(analyses(7), moves, evaluations), undefined);
+  });
+
+  it('ignores blunders past the opening', () => {
+    const engine = new StockfishEngine({ openingBookDepth: 8 });
+
+    assert.equal(engine.detectOpeningTrap(analyses(9), moves, evaluations), undefined);
+  });
+});
+
+describe('calculateConversionTechnique', () => {
+  it('scores a sloppy conversion of an early +4 below a clean one', () => {
+    const engine = new StockfishEngine();
+    const clean = [0, 450, 430, 500, 480, 600, 580, 800].map(score => evaluation(score));
+    const sloppy = [0, 450, 430, 200, 180, 450, 430, 150].map(score => evaluation(score));
+
+    const cleanTechnique = engine.calculateConversionTechnique(clean, true);
+    const sloppyTechnique = engine.calculateConversionTechnique(sloppy, true);
+
+    assert.equal(cleanTechnique, 100);
+    assert.ok(sloppyTechnique !== undefined && sloppyTechnique < 100, `expected ${sloppyTechnique} below the clean 100`);
+  });
+
+  it('has nothing to score for a player who was never winning', () => {
+    const engine = new StockfishEngine();
+    const evaluations = [0, 450, 430, 500].map(score => evaluation(score));
+
+    assert.equal(engine.calculateConversionTechnique(evaluations, false), undefined);
+  });
+});
+
+describe('classification trace', () => {
+  it('records the rule and numbers behind a blunder', () => {
+    const engine = new StockfishEngine();
+    // Black goes from -0.2 to +3.3 for White
+    const { classification, trace } = engine.classifyMoveWithTrace(evaluation(-20), evaluation(330), 'd8h4', 'g8f6', false);
+
+    assert.equal(classification, 'blunder');
+    assert.deepEqual(trace, {
+      rule: 'loss in blunder band (-250..-500)',
+      scoreBefore: 20,
+      scoreAfter: -330,
+      evaluationChange: -350,
+      threshold: -500,
+      notes: []
+    });
+  });
+
+  it('names the band of each classification', () => {
+    const engine = new StockfishEngine();
+    const ruleFor = (scoreAfter: number) =>
+      engine.classifyMoveWithTrace(evaluation(0), evaluation(scoreAfter), 'a1a2', 'e2e4').trace.rule;
+
+    assert.equal(ruleFor(-30), 'loss in good band (0..-50)');
+    assert.equal(ruleFor(-80), 'loss in inaccuracy band (-50..-100)');
+    assert.equal(ruleFor(-200), 'loss in mistake band (-100..-250)');
+    assert.equal(ruleFor(-400), 'loss in blunder band (-250..-500)');
+    assert.equal(ruleFor(-700), 'loss beyond blunder band (below -500)');
+  });
+
+  it('names the win percentage band under the lichess model', () => {
+    const engine = new StockfishEngine({ accuracyModel: 'lichess' });
+    const ruleFor = (scoreAfter: number) =>
+      engine.classifyMoveWithTrace(evaluation(0), evaluation(scoreAfter), 'a1a2', 'e2e4').trace.rule;
+
+    assert.equal(ruleFor(-20), 'win percentage loss in good band (0..5)');
+    assert.equal(ruleFor(-80), 'win percentage loss in inaccuracy band (5..10)');
+    assert.equal(ruleFor(-140), 'win percentage loss in mistake band (10..15)');
+    assert.equal(ruleFor(-400), 'win percentage loss in blunder band (15 or more)');
+  });
+
+  it('agrees with classifyMove', () => {
+    const engine = new StockfishEngine();
+    const before = evaluation(-20);
+    const after = evaluation(330);
+
+    assert.equal(
+      engine.classifyMove(before, after, 'd8h4', 'g8f6', false),
+      engine.classifyMoveWithTrace(before, after, 'd8h4', 'g8f6', false).classification
+    );
+  });
+});
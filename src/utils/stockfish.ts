@@ -1,4 +1,6 @@
-import { EngineEvaluation, StockfishConfig, MoveClassification } from '@/types/analysis';
+import { Chess } from 'chess.js';
+import { EngineEvaluation, StockfishConfig, MoveClassification, MoveAnalysis, ClassificationTrace, EndgameVerdict, ResultConsistency, EngineStats, CriticalPosition, OpeningTrap } from '@/types/analysis';
+import { ChessMove } from '@/types/chess';
 
 export type TacticalPattern = 
   | 'fork'
@@ -25,18 +27,82 @@ export interface TacticalAnalysis {
   description?: string;
 }
 
+export const MAX_ANALYSIS_DEPTH = 30;
+export const MAX_ANALYSIS_TIME = 30000; // 30 seconds per position
+export const MAX_ANALYSIS_MOVES = 300; // Full moves per game
+export const OPENING_BOOK_DEPTH = 15; // Plies
+export const BRILLIANT_CONFIRMATION_DEPTH = 6; // Extra plies used to verify sacrifices
+export const LOW_CONFIDENCE_THRESHOLD = 0.5;
+export const MATE_SCORE = 1000; // Centipawn stand-in for forced mates
+export const MISSED_MATE_RULE = 'missed forced mate'; // Trace rule for a non-best move that let a forced mate go
+export const DECIDED_WIN_PERCENTAGE = 5; // Below this (or above 100 minus it) the game is already decided
+
+// Win percentage points a move may give away before Lichess calls it an inaccuracy, mistake or blunder
+export const LICHESS_JUDGEMENT_THRESHOLDS = {
+  inaccuracy: 5,
+  mistake: 10,
+  blunder: 15
+};
+
+// Output precision (decimal places) so reported numbers stay stable and readable
+export const ACCURACY_PRECISION = 1;
+export const CONFIDENCE_PRECISION = 2;
+export const WIN_PERCENTAGE_PRECISION = 1;
+export const DEPTH_PRECISION = 1;
+
+// The one place engine settings get their defaults; every entry point goes through applyConfigDefaults
+export const DEFAULT_STOCKFISH_CONFIG: Required<StockfishConfig> = {
+  depth: 15,
+  time: 1000, // Per position
+  threads: 1,
+  hash: 128,
+  maxDepth: MAX_ANALYSIS_DEPTH,
+  maxTime: MAX_ANALYSIS_TIME,
+  maxAnalysisMoves: MAX_ANALYSIS_MOVES,
+  openingBookDepth: OPENING_BOOK_DEPTH,
+  accuracyModel: 'chesscom',
+  criticalMomentThreshold: 200,
+  decidedPositionWeight: 0.2
+};
+
+export function applyConfigDefaults(config?: Partial<StockfishConfig>): Required<StockfishConfig> {
+  const resolved = { ...DEFAULT_STOCKFISH_CONFIG };
+  if (config) {
+    // Skip explicit undefineds so they don't erase a default
+    (Object.keys(config) as (keyof StockfishConfig)[]).forEach(key => {
+      if (config[key] !== undefined) {
+        Object.assign(resolved, { [key]: config[key] });
+      }
+    });
+  }
+  return resolved;
+}
+
 export class StockfishEngine {
   private isReady = false;
-  private config: StockfishConfig = {
-    depth: 15,
-    time: 1000,
-    threads: 1,
-    hash: 128
-  };
+  private config: Required<StockfishConfig>;
 
   constructor(config?: Partial<StockfishConfig>) {
-    if (config) {
-      this.config = { ...this.config, ...config };
+    this.config = applyConfigDefaults(config);
+    this.validateLimits(this.config.depth, this.config.time);
+  }
+
+  private validateLimits(depth: number, time?: number): void {
+    const { maxDepth, maxTime } = this.config;
+
+    if (depth < 1 || depth > maxDepth) {
+      throw new Error(`Analysis depth must be between 1 and ${maxDepth}, got ${depth}`);
+    }
+    if (time !== undefined && (time <= 0 || time > maxTime)) {
+      throw new Error(`Analysis time must be between 1 and ${maxTime}ms, got ${time}ms`);
+    }
+  }
+
+  validateGameLength(plies: number): void {
+    const fullMoves = Math.ceil(plies / 2);
+    const { maxAnalysisMoves } = this.config;
+    if (fullMoves > maxAnalysisMoves) {
+      throw new Error(`Game is too long to analyze (${fullMoves} moves, limit is ${maxAnalysisMoves})`);
     }
   }
 
@@ -56,6 +122,15 @@ export class StockfishEngine {
     if (!this.isReady) {
       throw new Error('Stockfish engine not ready');
     }
+    if (depth !== undefined) {
+      this.validateLimits(depth);
+    }
+
+    // Checkmate and stalemate have no best move, so there is nothing to search
+    const terminalEvaluation = this.getTerminalEvaluation(fen);
+    if (terminalEvaluation) {
+      return terminalEvaluation;
+    }
 
     // Mock analysis - simulate analysis time
     const analysisTime = Math.random() * 500 + 200; // 200-700ms
@@ -71,10 +146,35 @@ export class StockfishEngine {
       time: analysisTime,
       mate: Math.random() < 0.05 ? Math.floor(Math.random() * 10 + 1) : undefined
     };
+    mockEvaluation.confidence = getEvaluationConfidence(mockEvaluation);
 
     return mockEvaluation;
   }
 
+  private getTerminalEvaluation(fen: string): EngineEvaluation | null {
+    const chess = new Chess(fen);
+    if (!chess.isCheckmate() && !chess.isStalemate()) {
+      return null;
+    }
+
+    // The side to move has been mated, so the score favors the other side
+    const isCheckmate = chess.isCheckmate();
+    const score = isCheckmate
+      ? (chess.turn() === 'w' ? -MATE_SCORE : MATE_SCORE)
+      : 0;
+
+    return {
+      score,
+      depth: 0,
+      bestMove: '',
+      principalVariation: [],
+      nodes: 0,
+      time: 0,
+      mate: isCheckmate ? 0 : undefined,
+      confidence: 1
+    };
+  }
+
   private generateMockMove(): string {
     const files = ['a', 'b', 'c', 'd', 'e', 'f', 'g', 'h'];
     const ranks = ['1', '2', '3', '4', '5', '6', '7', '8'];
@@ -96,30 +196,91 @@ export class StockfishEngine {
     positionBefore: EngineEvaluation,
     positionAfter: EngineEvaluation,
     playedMove: string,
-    bestMove: string
+    bestMove: string,
+    isWhiteMove = true
   ): MoveClassification {
-    const scoreDiff = Math.abs(positionBefore.score - positionAfter.score);
-    const isPlayerTurn = positionBefore.score > 0; // Assuming white to move
-    
-    // Adjust score based on whose turn it is
-    const adjustedScoreBefore = isPlayerTurn ? positionBefore.score : -positionBefore.score;
-    const adjustedScoreAfter = isPlayerTurn ? -positionAfter.score : positionAfter.score;
-    const evaluation = adjustedScoreAfter - adjustedScoreBefore;
+    return this.classifyMoveWithTrace(positionBefore, positionAfter, playedMove, bestMove, isWhiteMove).classification;
+  }
+
+  // Same rules as classifyMove, also reporting which rule fired and the numbers it used
+  classifyMoveWithTrace(
+    positionBefore: EngineEvaluation,
+    positionAfter: EngineEvaluation,
+    playedMove: string,
+    bestMove: string,
+    isWhiteMove = true
+  ): { classification: MoveClassification; trace: ClassificationTrace } {
+    const scoreBefore = toPlayerPerspective(positionBefore.score, isWhiteMove);
+    const scoreAfter = toPlayerPerspective(positionAfter.score, isWhiteMove);
+    const evaluation = scoreAfter - scoreBefore;
+    const result = (classification: MoveClassification, rule: string, threshold?: number) => ({
+      classification,
+      trace: { rule, scoreBefore, scoreAfter, evaluationChange: evaluation, threshold, notes: [] }
+    });
+
+    if (playedMove !== bestMove && this.isMissedMate(positionBefore, positionAfter, isWhiteMove)) {
+      return result('miss', MISSED_MATE_RULE);
+    }
 
     // Check if played move is the best move
     if (playedMove === bestMove) {
-      if (evaluation > 200) return 'brilliant';
-      if (evaluation > 100) return 'great';
-      return 'best';
+      if (evaluation > 200) return result('brilliant', 'best move, gain above threshold', 200);
+      if (evaluation > 100) return result('great', 'best move, gain above threshold', 100);
+      return result('best', 'matches engine best move');
+    }
+
+    if (this.config.accuracyModel === 'lichess') {
+      // Lichess judges a move by the win percentage it gave away rather than by centipawns
+      const loss = winPercentage(scoreBefore) - winPercentage(scoreAfter);
+      const { inaccuracy, mistake, blunder } = LICHESS_JUDGEMENT_THRESHOLDS;
+      if (loss < inaccuracy) return result('good', `win percentage loss in good band (0..${inaccuracy})`, inaccuracy);
+      if (loss < mistake) return result('inaccuracy', `win percentage loss in inaccuracy band (${inaccuracy}..${mistake})`, mistake);
+      if (loss < blunder) return result('mistake', `win percentage loss in mistake band (${mistake}..${blunder})`, blunder);
+      return result('blunder', `win percentage loss in blunder band (${blunder} or more)`, blunder);
     }
 
     // Classify based on evaluation loss
-    if (evaluation >= -50) return 'good';
-    if (evaluation >= -100) return 'inaccuracy';
-    if (evaluation >= -250) return 'mistake';
-    if (evaluation >= -500) return 'blunder';
+    if (evaluation >= -50) return result('good', 'loss in good band (0..-50)', -50);
+    if (evaluation >= -100) return result('inaccuracy', 'loss in inaccuracy band (-50..-100)', -100);
+    if (evaluation >= -250) return result('mistake', 'loss in mistake band (-100..-250)', -250);
+    if (evaluation >= -500) return result('blunder', 'loss in blunder band (-250..-500)', -500);
     
-    return 'miss';
+    return result('miss', 'loss beyond blunder band (below -500)', -500);
+  }
+
+  async confirmBrilliantMove(
+    fenAfter: string,
+    positionAfter: EngineEvaluation,
+    isWhiteMove: boolean
+  ): Promise<boolean> {
+    const confirmationDepth = Math.min(positionAfter.depth + BRILLIANT_CONFIRMATION_DEPTH, this.config.maxDepth);
+    const deeperEvaluation = await this.analyzePosition(fenAfter, confirmationDepth);
+
+    // Compare from the perspective of the player who made the move
+    const shallowScore = toPlayerPerspective(this.getMateAdjustedScore(positionAfter), isWhiteMove);
+    const deepScore = toPlayerPerspective(this.getMateAdjustedScore(deeperEvaluation), isWhiteMove);
+
+    // The sacrifice is sound if the deeper search still sees an advantage close to the shallow one
+    return deepScore > 0 && deepScore >= shallowScore - 100;
+  }
+
+  isMissedMate(
+    positionBefore: EngineEvaluation,
+    positionAfter: EngineEvaluation,
+    isWhiteMove: boolean
+  ): boolean {
+    if (positionBefore.mate === undefined) return false;
+
+    // Mate scores share the white-relative sign convention of centipawn scores
+    const hadMate = toPlayerPerspective(positionBefore.mate, isWhiteMove) > 0;
+    if (!hadMate) return false;
+
+    // The move delivered checkmate
+    if (positionAfter.mate === 0) return false;
+    if (positionAfter.mate === undefined) return true;
+
+    const stillMating = toPlayerPerspective(positionAfter.mate, isWhiteMove) > 0;
+    return !stillMating || Math.abs(positionAfter.mate) >= Math.abs(positionBefore.mate);
   }
 
   // New tactical pattern recognition methods
@@ -134,6 +295,11 @@ export class StockfishEngine {
     let threatLevel: TacticalAnalysis['threatLevel'] = 'low';
     let description = '';
 
+    // The move delivered checkmate (mate 0): the game is over, so there is no pattern or mate distance to report
+    if (positionAfter.mate === 0) {
+      return { patterns: ['none'], isForcing: true, isTactical: true, threatLevel: 'critical', description: 'Checkmate' };
+    }
+
     const scoreDiff = Math.abs(positionBefore.score - positionAfter.score);
     const evaluationSwing = positionAfter.score - positionBefore.score;
 
@@ -233,7 +399,7 @@ export class StockfishEngine {
       // Large evaluation swings indicate critical moments
       const swing = Math.abs(curr.score - prev.score);
       
-      if (swing > 200) {
+      if (swing > this.config.criticalMomentThreshold) {
         criticalMoments.push(i);
       }
       
@@ -251,65 +417,369 @@ export class StockfishEngine {
     return criticalMoments;
   }
 
-  analyzeGamePhases(moves: any[], evaluations: EngineEvaluation[]): {
+  analyzeGamePhases(moves: any[], evaluations: EngineEvaluation[], whiteMovesFirst = true, skipPositions: boolean[] = []): {
     opening: number;
     middlegame: number;
     endgame: number;
-    openingAccuracy: number;
-    middlegameAccuracy: number;
-    endgameAccuracy: number;
+    openingAccuracy?: number;
+    middlegameAccuracy?: number;
+    endgameAccuracy?: number;
   } {
     // Simple heuristics for game phase detection
     const totalMoves = moves.length;
     
-    // Opening typically ends around move 10-15
-    const openingEnd = Math.min(Math.floor(totalMoves * 0.25), 15);
+    // Opening ends at the configured book depth, or earlier for short games
+    const openingEnd = Math.min(Math.floor(totalMoves * 0.25), this.config.openingBookDepth);
     
     // Endgame typically starts when few pieces remain (mock detection)
     const endgameStart = Math.max(Math.floor(totalMoves * 0.75), openingEnd + 10);
     
-    // Calculate phase accuracies
-    const openingEvals = evaluations.slice(0, openingEnd);
-    const middlegameEvals = evaluations.slice(openingEnd, endgameStart);
-    const endgameEvals = evaluations.slice(endgameStart);
+    // Calculate phase accuracies over both players' moves (ply i goes from evaluations[i - 1] to evaluations[i]),
+    // leaving out moves from skipped positions just like calculateAccuracy
+    const pliesBetween = (start: number, end: number) => {
+      const plies: number[] = [];
+      for (let ply = start + 1; ply <= Math.min(end, evaluations.length - 1); ply++) {
+        if (!skipPositions[ply - 1]) {
+          plies.push(ply);
+        }
+      }
+      return plies;
+    };
     
     return {
       opening: openingEnd,
       middlegame: endgameStart,
       endgame: totalMoves,
-      openingAccuracy: this.calculateAccuracy(openingEvals),
-      middlegameAccuracy: this.calculateAccuracy(middlegameEvals),
-      endgameAccuracy: this.calculateAccuracy(endgameEvals)
+      openingAccuracy: this.calculateAccuracyForPlies(evaluations, pliesBetween(0, openingEnd), whiteMovesFirst),
+      middlegameAccuracy: this.calculateAccuracyForPlies(evaluations, pliesBetween(openingEnd, endgameStart), whiteMovesFirst),
+      endgameAccuracy: this.calculateAccuracyForPlies(evaluations, pliesBetween(endgameStart, totalMoves), whiteMovesFirst)
     };
   }
 
-  calculateAccuracy(evaluations: EngineEvaluation[]): number {
-    if (evaluations.length === 0) return 0;
+  detectEndgameVerdict(
+    evaluations: EngineEvaluation[],
+    moves: ChessMove[],
+    endgameStart: number
+  ): EndgameVerdict | undefined {
+    const decisiveScore = 300;
+    const drawnScore = 100;
+
+    // evaluations[i] is the position after ply i (moves[i - 1]), so the first endgame move is endgameStart + 1
+    for (let i = Math.max(1, endgameStart + 1); i < Math.min(evaluations.length, moves.length + 1); i++) {
+      const isWhiteMove = moves[i - 1].color === 'w';
+      const scoreBefore = toPlayerPerspective(this.getMateAdjustedScore(evaluations[i - 1]), isWhiteMove);
+      const scoreAfter = toPlayerPerspective(this.getMateAdjustedScore(evaluations[i]), isWhiteMove);
+
+      let from: EndgameVerdict['from'] | null = null;
+      let to: EndgameVerdict['to'] | null = null;
+
+      if (scoreBefore >= decisiveScore && scoreAfter < decisiveScore) {
+        from = 'win';
+        to = scoreAfter <= -decisiveScore ? 'loss' : 'draw';
+      } else if (Math.abs(scoreBefore) <= drawnScore && scoreAfter <= -decisiveScore) {
+        from = 'draw';
+        to = 'loss';
+      }
+
+      if (from && to) {
+        const moveNumber = moves[i - 1].moveNumber;
+        const label = from === 'win' ? 'Winning' : 'Drawn';
+        return {
+          moveNumber,
+          player: isWhiteMove ? 'white' : 'black',
+          from,
+          to,
+          description: `${label} endgame converted to ${to} at move ${moveNumber}`
+        };
+      }
+    }
+
+    return undefined;
+  }
+
+  detectDecisiveMoment(evaluations: EngineEvaluation[], moves: ChessMove[]): CriticalPosition | undefined {
+    const minimumSwing = 20; // Win percentage points
+    const winChances = evaluations
+      .slice(0, moves.length + 1)
+      .map(evaluation => winPercentage(this.getMateAdjustedScore(evaluation)));
+
+    let decisiveIndex = -1;
+    let largestSwing = 0;
+
+    for (let i = 1; i < winChances.length; i++) {
+      const swing = winChances[i] - winChances[i - 1];
+      if (Math.abs(swing) < minimumSwing || Math.abs(swing) <= largestSwing) continue;
+
+      // Transient swings get corrected later; the decisive one keeps at least half its gain
+      const direction = Math.sign(swing);
+      const isSustained = winChances
+        .slice(i)
+        .every(chance => direction * (chance - winChances[i - 1]) >= Math.abs(swing) / 2);
+
+      if (isSustained) {
+        decisiveIndex = i;
+        largestSwing = Math.abs(swing);
+      }
+    }
+
+    if (decisiveIndex === -1) return undefined;
+
+    const before = this.getMateAdjustedScore(evaluations[decisiveIndex - 1]);
+    const after = this.getMateAdjustedScore(evaluations[decisiveIndex]);
+    const advantage = after > before ? 'white' : 'black';
+    const moveNumber = moves[decisiveIndex - 1].moveNumber;
+    const side = advantage === 'white' ? 'White' : 'Black';
+    const chanceBefore = advantage === 'white' ? winChances[decisiveIndex - 1] : 100 - winChances[decisiveIndex - 1];
+    const chanceAfter = advantage === 'white' ? winChances[decisiveIndex] : 100 - winChances[decisiveIndex];
+
+    return {
+      moveNumber,
+      beforeEval: before,
+      afterEval: after,
+      advantage,
+      description: `Move ${moveNumber} decided the game: ${side}'s winning chances rose from ${formatWinPercentage(chanceBefore)} to ${formatWinPercentage(chanceAfter)} and never came back`
+    };
+  }
+
+  detectMissedOpportunities(evaluations: EngineEvaluation[], moves: ChessMove[]): CriticalPosition[] {
+    const winningChance = 70; // Win percentage needed to count as a real opportunity
+    const minimumDrop = 20;
+    const opportunities: CriticalPosition[] = [];
+    const whiteChances = evaluations
+      .slice(0, moves.length + 1)
+      .map(evaluation => winPercentage(this.getMateAdjustedScore(evaluation)));
+
+    // Ply i is the opponent's error, ply i + 1 (moves[i]) is the reply that failed to punish it
+    for (let i = 1; i + 1 < whiteChances.length; i++) {
+      const isWhiteReply = moves[i].color === 'w';
+      const toPlayer = (chance: number) => isWhiteReply ? chance : 100 - chance;
+
+      const beforeError = toPlayer(whiteChances[i - 1]);
+      const afterError = toPlayer(whiteChances[i]);
+      const afterReply = toPlayer(whiteChances[i + 1]);
+
+      const handedWin = beforeError < winningChance && afterError >= winningChance;
+      const letItGo = afterError - afterReply >= minimumDrop && afterReply < winningChance;
+
+      if (handedWin && letItGo) {
+        const moveNumber = moves[i].moveNumber;
+        const side = isWhiteReply ? 'White' : 'Black';
+        opportunities.push({
+          moveNumber,
+          beforeEval: this.getMateAdjustedScore(evaluations[i]),
+          afterEval: this.getMateAdjustedScore(evaluations[i + 1]),
+          advantage: isWhiteReply ? 'white' : 'black',
+          description: `Move ${moveNumber}: ${side} missed the chance to punish the opponent's error (winning chances fell from ${formatWinPercentage(afterError)} to ${formatWinPercentage(afterReply)})`
+        });
+      }
+    }
+
+    return opportunities;
+  }
+
+  // Technique (0-100) once the player first reached a winning position: the Lichess accuracy curve
+  // applied to the win percentage given back on their moves from then on. Undefined if never winning.
+  calculateConversionTechnique(
+    evaluations: EngineEvaluation[],
+    isWhite: boolean,
+    whiteMovesFirst = true
+  ): number | undefined {
+    const playerScore = (evaluation: EngineEvaluation) =>
+      toPlayerPerspective(this.getMateAdjustedScore(evaluation), isWhite);
+
+    const winningFrom = evaluations.findIndex(evaluation => playerScore(evaluation) >= EVALUATION_THRESHOLDS.winning);
+    if (winningFrom === -1) return undefined;
 
     let totalLoss = 0;
     let moveCount = 0;
+    for (let i = winningFrom + 1; i < evaluations.length; i++) {
+      const isWhiteMove = isWhitePly(i, whiteMovesFirst);
+      if (isWhiteMove !== isWhite) continue;
 
-    for (let i = 1; i < evaluations.length; i++) {
-      const prevEval = evaluations[i - 1];
-      const currEval = evaluations[i];
+      const before = winPercentage(playerScore(evaluations[i - 1]));
+      const after = winPercentage(playerScore(evaluations[i]));
+      totalLoss += Math.max(0, before - after);
+      moveCount++;
+    }
+    if (moveCount === 0) return undefined;
+
+    return roundTo(lichessAccuracy(totalLoss / moveCount), ACCURACY_PRECISION);
+  }
+
+  // Heuristic: a blunder within the opening (openingBookDepth plies) that the opponent immediately
+  // punished with the engine's forcing reply (a check or capture) is treated as a trap
+  detectOpeningTrap(moveAnalyses: MoveAnalysis[], moves: ChessMove[], evaluations: EngineEvaluation[]): OpeningTrap | undefined {
+    const openingPlies = Math.min(moveAnalyses.length - 1, this.config.openingBookDepth);
+    for (let i = 0; i < openingPlies; i++) {
+      const move = moves[i];
+
+      const { classification } = moveAnalyses[i];
+      if (classification !== 'blunder' && classification !== 'miss') continue;
+
+      const reply = moves[i + 1];
+      const isForcing = /[+#x]/.test(reply.san);
+      if (reply.from + reply.to !== evaluations[i + 1]?.bestMove || !isForcing) continue;
+
+      const player = move.color === 'w' ? 'white' : 'black';
+      const notation = `${move.moveNumber}${move.color === 'w' ? '.' : '...'} ${move.san}`;
+      return {
+        moveNumber: move.moveNumber,
+        player,
+        move: move.san,
+        refutation: reply.san,
+        description: `${player === 'white' ? 'White' : 'Black'} fell into an opening trap with ${notation}, refuted by ${reply.san}`
+      };
+    }
+
+    return undefined;
+  }
+
+  // A decisive result against the final evaluation is expected when the game ended on the
+  // clock or by resignation, so the PGN termination is used to explain it instead of flagging it
+  checkResultConsistency(evaluations: EngineEvaluation[], result: string, termination?: string): ResultConsistency {
+    const decisiveScore = 300;
+    const finalEvaluation = evaluations.length > 0
+      ? this.getMateAdjustedScore(evaluations[evaluations.length - 1])
+      : 0;
+
+    const whiteUpset = result === '1-0' && finalEvaluation <= -decisiveScore;
+    const blackUpset = result === '0-1' && finalEvaluation >= decisiveScore;
+
+    if (whiteUpset || blackUpset) {
+      const winner = whiteUpset ? 'White' : 'Black';
+      const loser = whiteUpset ? 'Black' : 'White';
+      const reason = termination?.toLowerCase() ?? '';
+
+      if (reason.includes('time')) {
+        return { isConsistent: true, finalEvaluation, note: `${loser} lost on time in a winning position` };
+      }
+      if (reason.includes('resign')) {
+        return { isConsistent: true, finalEvaluation, note: `${loser} resigned in a winning position` };
+      }
+      return {
+        isConsistent: false,
+        finalEvaluation,
+        note: `${winner} won despite a losing final position (resignation, time or a late blunder)`
+      };
+    }
+
+    let note: string | undefined;
+    if (result === '1/2-1/2' && Math.abs(finalEvaluation) >= decisiveScore) {
+      const side = finalEvaluation > 0 ? 'White' : 'Black';
+      note = `Game was drawn although ${side} had a winning position`;
+    }
+
+    return {
+      isConsistent: note === undefined,
+      finalEvaluation,
+      note
+    };
+  }
+
+  // Game quality (0-100) for both sides combined:
+  //   60% average accuracy of the two players
+  //   25% share of positions that were still contested (no side winning)
+  //   15% critical moments reached from a contested position, 20 points each up to 100
+  // A clean, sharp game scores high; a sloppy game that is decided early scores low.
+  // Only accuracies that could be scored are averaged; with neither there is no quality to report.
+  calculateGameQuality(
+    whiteAccuracy: number | undefined,
+    blackAccuracy: number | undefined,
+    evaluations: EngineEvaluation[],
+    criticalMoments: number[]
+  ): number | undefined {
+    if (evaluations.length === 0) return 0;
+
+    const accuracies = [whiteAccuracy, blackAccuracy].filter((accuracy): accuracy is number => accuracy !== undefined);
+    if (accuracies.length === 0) return undefined;
+
+    const isContested = (evaluation: EngineEvaluation) =>
+      Math.abs(this.getMateAdjustedScore(evaluation)) < EVALUATION_THRESHOLDS.winning;
+
+    const averageAccuracy = accuracies.reduce((sum, accuracy) => sum + accuracy, 0) / accuracies.length;
+    const contestedShare = evaluations.filter(isContested).length / evaluations.length * 100;
+    const genuineMoments = new Set(
+      criticalMoments.filter(index => index > 0 && evaluations[index - 1] && isContested(evaluations[index - 1]))
+    ).size;
+    const sharpness = Math.min(100, genuineMoments * 20);
+
+    const quality = averageAccuracy * 0.6 + contestedShare * 0.25 + sharpness * 0.15;
+    return roundTo(quality, ACCURACY_PRECISION);
+  }
+
+  private getMateAdjustedScore(evaluation: EngineEvaluation): number {
+    // A delivered mate (mate 0) has no sign of its own; its score already carries the winner
+    if (evaluation.mate !== undefined && evaluation.mate !== 0) {
+      return evaluation.mate > 0 ? MATE_SCORE : -MATE_SCORE;
+    }
+    return evaluation.score;
+  }
+
+  // Accuracy for one player over the full evaluation history. Only their own moves count, each
+  // scored from the position before it to the position after it. Moves that start from a position
+  // flagged in skipPositions (e.g. a dead draw) are left out. Undefined when no move is left to score.
+  calculateAccuracy(
+    evaluations: EngineEvaluation[],
+    isWhite: boolean,
+    whiteMovesFirst = true,
+    skipPositions: boolean[] = []
+  ): number | undefined {
+    const plies: number[] = [];
+    for (let ply = 1; ply < evaluations.length; ply++) {
+      if (isWhitePly(ply, whiteMovesFirst) === isWhite && !skipPositions[ply - 1]) {
+        plies.push(ply);
+      }
+    }
+    return this.calculateAccuracyForPlies(evaluations, plies, whiteMovesFirst);
+  }
+
+  private calculateAccuracyForPlies(
+    evaluations: EngineEvaluation[],
+    plies: number[],
+    whiteMovesFirst: boolean
+  ): number | undefined {
+    if (plies.length === 0) return undefined;
+
+    const isLichess = this.config.accuracyModel === 'lichess';
+    // Per move: centipawn loss (chess.com) or the move's own accuracy (lichess), with its weight
+    const scoredMoves: { value: number; weight: number }[] = [];
+
+    for (const ply of plies) {
+      const prevEval = evaluations[ply - 1];
+      const currEval = evaluations[ply];
       
       // Calculate evaluation loss (from perspective of player who moved)
-      const isWhiteMove = i % 2 === 1;
-      const scoreBefore = isWhiteMove ? prevEval.score : -prevEval.score;
-      const scoreAfter = isWhiteMove ? -currEval.score : currEval.score;
+      const isWhiteMove = isWhitePly(ply, whiteMovesFirst);
+      const scoreBefore = toPlayerPerspective(prevEval.score, isWhiteMove);
+      const scoreAfter = toPlayerPerspective(currEval.score, isWhiteMove);
+
+      // Errors in hopelessly lost or trivially won positions barely matter, so they count for less
+      const chanceBefore = winPercentage(scoreBefore);
+      const isDecided = chanceBefore < DECIDED_WIN_PERCENTAGE || chanceBefore > 100 - DECIDED_WIN_PERCENTAGE;
+      const weight = isDecided ? this.config.decidedPositionWeight : 1;
       
-      const loss = Math.max(0, scoreBefore - scoreAfter);
-      totalLoss += loss;
-      moveCount++;
+      scoredMoves.push({
+        // Lichess rates each move on its own from the win percentage it gave away, then averages the ratings
+        value: isLichess
+          ? lichessAccuracy(Math.max(0, chanceBefore - winPercentage(scoreAfter)))
+          : Math.max(0, scoreBefore - scoreAfter),
+        weight
+      });
     }
 
-    const averageLoss = totalLoss / moveCount;
-    
+    // A zero weight with every move in a decided position leaves nothing to weight, so count all moves equally
+    const totalWeight = scoredMoves.reduce((sum, move) => sum + move.weight, 0);
+    const average = totalWeight > 0
+      ? scoredMoves.reduce((sum, move) => sum + move.value * move.weight, 0) / totalWeight
+      : scoredMoves.reduce((sum, move) => sum + move.value, 0) / scoredMoves.length;
+
     // Convert to accuracy percentage
-    // Formula inspired by chess.com's accuracy calculation
-    const accuracy = Math.max(0, 100 - (averageLoss / 10));
+    const accuracy = isLichess
+      ? average
+      // Chess.com style: one point of accuracy per 10 centipawns of average loss
+      : Math.max(0, 100 - (average / 10));
     
-    return Math.round(accuracy * 10) / 10;
+    return roundTo(accuracy, ACCURACY_PRECISION);
   }
 
   stop(): void {
@@ -322,6 +792,64 @@ export class StockfishEngine {
   }
 }
 
+// Accuracy as shown to users, with a dash when no move could be scored
+export function formatAccuracy(accuracy: number | undefined): string {
+  return accuracy === undefined ? '—' : `${accuracy.toFixed(ACCURACY_PRECISION)}%`;
+}
+
+// Win percentage (or win percentage points) as shown to users
+export function formatWinPercentage(chance: number): string {
+  return `${chance.toFixed(WIN_PERCENTAGE_PRECISION)}%`;
+}
+
+export function roundTo(value: number, decimals: number): number {
+  const factor = Math.pow(10, decimals);
+  return Math.round(value * factor) / factor;
+}
+
+export function calculateEngineStats(evaluations: EngineEvaluation[]): EngineStats {
+  const totalNodes = evaluations.reduce((sum, evaluation) => sum + evaluation.nodes, 0);
+  const totalTime = evaluations.reduce((sum, evaluation) => sum + evaluation.time, 0);
+  const totalDepth = evaluations.reduce((sum, evaluation) => sum + evaluation.depth, 0);
+
+  return {
+    totalNodes,
+    totalTime: Math.round(totalTime),
+    averageDepth: evaluations.length > 0 ? roundTo(totalDepth / evaluations.length, DEPTH_PRECISION) : 0,
+    positionsAnalyzed: evaluations.length
+  };
+}
+
+// Depth dominates trust in an evaluation; node count separates slow and fast searches at equal depth
+export function getEvaluationConfidence(evaluation: EngineEvaluation): number {
+  const depthFactor = Math.min(1, evaluation.depth / 24);
+  const nodeFactor = Math.min(1, Math.log10(Math.max(1, evaluation.nodes)) / 7);
+  const confidence = depthFactor * 0.7 + nodeFactor * 0.3;
+
+  return roundTo(confidence, CONFIDENCE_PRECISION);
+}
+
+// Ply i is the move from evaluations[i - 1] to evaluations[i]; the first ply is White's unless the game starts with Black
+function isWhitePly(ply: number, whiteMovesFirst: boolean): boolean {
+  return (ply % 2 === 1) === whiteMovesFirst;
+}
+
+// Engine scores are always White-relative (positive favours White). This is the one place
+// a score is turned into a given player's point of view; everything else should call it.
+export function toPlayerPerspective(score: number, isWhite: boolean): number {
+  return isWhite ? score : -score;
+}
+
+// Lichess win percentage (0-100) for a centipawn score
+export function winPercentage(score: number): number {
+  return 50 + 50 * (2 / (1 + Math.exp(-0.00368208 * score)) - 1);
+}
+
+// Lichess accuracy (0-100) for a loss in win percentage points: 103.1668 * e^(-0.04354 * loss) - 3.1669
+export function lichessAccuracy(winPercentLoss: number): number {
+  return Math.min(100, Math.max(0, 103.1668 * Math.exp(-0.04354 * winPercentLoss) - 3.1669));
+}
+
 // Singleton instance for global use
 let stockfishInstance: StockfishEngine | null = null;
 
@@ -335,6 +863,10 @@ export async function getStockfishEngine(): Promise<StockfishEngine> {
 
 export function convertScoreToString(score: number, mate?: number): string {
   if (mate !== undefined) {
+    // mate 0 is a checkmate on the board, so show the result; the score carries the winner
+    if (mate === 0) {
+      return score > 0 ? '1-0' : '0-1';
+    }
     return `M${mate}`;
   }
   
@@ -344,8 +876,46 @@ export function convertScoreToString(score: number, mate?: number): string {
   return `${sign}${pawnValue.toFixed(1)}`;
 }
 
+// Shared evaluation bands (centipawns) so every view assesses positions the same way
+export const EVALUATION_THRESHOLDS = {
+  equal: 50,
+  advantage: 150,
+  winning: 300
+};
+
+export type PositionAssessment = 'equal' | 'slight_advantage' | 'advantage' | 'winning';
+
+export function assessPosition(score: number): PositionAssessment {
+  const absScore = Math.abs(score);
+  if (absScore < EVALUATION_THRESHOLDS.equal) return 'equal';
+  if (absScore < EVALUATION_THRESHOLDS.advantage) return 'slight_advantage';
+  if (absScore < EVALUATION_THRESHOLDS.winning) return 'advantage';
+  return 'winning';
+}
+
 export function getScoreColor(score: number): string {
-  if (Math.abs(score) < 50) return 'text-gray-600';
+  if (assessPosition(score) === 'equal') return 'text-gray-600';
   if (score > 0) return 'text-green-600';
   return 'text-red-600';
-} 
\ No newline at end of file
+}
+
+export interface ClassificationDisplay {
+  label: string;
+  symbol: string;
+  badgeColor: string;
+  dotColor: string;
+}
+
+// Single source for how each classification is shown; keyed by the type so a new classification can't be left out
+export const CLASSIFICATION_DISPLAY: Record<MoveClassification, ClassificationDisplay> = {
+  brilliant: { label: 'Brilliant', symbol: '!!', badgeColor: 'text-cyan-600 bg-cyan-50', dotColor: 'bg-cyan-500' },
+  great: { label: 'Great', symbol: '!', badgeColor: 'text-blue-600 bg-blue-50', dotColor: 'bg-blue-500' },
+  best: { label: 'Best', symbol: '★', badgeColor: 'text-green-600 bg-green-50', dotColor: 'bg-green-500' },
+  good: { label: 'Good', symbol: '✓', badgeColor: 'text-green-700 bg-green-50', dotColor: 'bg-green-400' },
+  inaccuracy: { label: 'Inaccuracy', symbol: '?!', badgeColor: 'text-yellow-600 bg-yellow-50', dotColor: 'bg-yellow-500' },
+  mistake: { label: 'Mistake', symbol: '?', badgeColor: 'text-orange-600 bg-orange-50', dotColor: 'bg-orange-500' },
+  blunder: { label: 'Blunder', symbol: '??', badgeColor: 'text-red-600 bg-red-50', dotColor: 'bg-red-500' },
+  miss: { label: 'Miss', symbol: '✗', badgeColor: 'text-red-700 bg-red-50', dotColor: 'bg-red-600' }
+};
+
+export const MOVE_CLASSIFICATIONS = Object.keys(CLASSIFICATION_DISPLAY) as MoveClassification[];
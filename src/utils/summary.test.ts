@@ -0,0 +1,94 @@
+import { describe, it } from 'node:test';
+import assert from 'node:assert/strict';
+import { EngineEvaluation, GameAnalysis, MoveAnalysis, MoveClassification, PlayerStatistics } from '@/types/analysis';
+import { ChessMove, GameInfo } from '@/types/chess';
+import { generateGameSummary } from '@/utils/summary';
+
+const sans = ['e4', 'e5', 'Nf3', 'Nc6', 'Bc4', 'Nd4', 'Nxe5', 'Qg5', 'Nxf7', 'Qxg2'];
+
+const moves = sans.map((san, i): ChessMove => ({
+  from: 'a1',
+  to: 'a2',
+  piece: 'p',
+  san,
+  fen: '',
+  moveNumber: Math.floor(i / 2) + 1,
+  color: i % 2 === 0 ? 'w' : 'b'
+}));
+
+function stats(accuracy: number): PlayerStatistics {
+  return { accuracy, brilliant: 0, great: 0, best: 0, good: 0, inaccuracy: 0, mistake: 0, blunder: 0, miss: 0 };
+}
+
+// An analysis of the game above where every move is good apart from the given classifications
+function analysisWith(classifications: Record<number, MoveClassification>, overrides: Partial<GameAnalysis> = {}): GameAnalysis {
+  const evaluation: EngineEvaluation = { score: 0, depth: 20, bestMove: '', principalVariation: [], nodes: 0, time: 0 };
+  return {
+    moves: moves.map((move, i): MoveAnalysis => ({
+      move: move.from + move.to,
+      san: move.san,
+      evaluation,
+      classification: classifications[i] ?? 'good'
+    })),
+    whiteStats: stats(91.2),
+    blackStats: stats(64.8),
+    gamePhases: { opening: 4, middlegame: 8, endgame: 10 },
+    criticalMoments: [],
+    evaluationHistory: [],
+    phaseAnalysis: { openingAccuracy: 90, middlegameAccuracy: 80, endgameAccuracy: 80 },
+    ...overrides
+  };
+}
+
+const gameInfo: GameInfo = { white: 'Anna', black: 'Boris', result: '1-0' };
+
+describe('generateGameSummary', () => {
+  it('names the move number of a known blunder', () => {
+    // Black's 4...Qg5 (index 7)
+    const summary = generateGameSummary(analysisWith({ 7: 'blunder' }), moves, gameInfo);
+
+    assert.match(summary, /Boris blundered on move 4 \(4\.\.\. Qg5\)\./);
+    assert.match(summary, /Anna made no blunders\./);
+    assert.match(summary, /Anna won with 91\.2% accuracy against 64\.8%\./);
+  });
+
+  it('shows a dash for a player with no scored moves and skips an unscored opening', () => {
+    const analysis = analysisWith({}, {
+      blackStats: { ...stats(0), accuracy: undefined },
+      phaseAnalysis: { middlegameAccuracy: 80, endgameAccuracy: 80 }
+    });
+    const summary = generateGameSummary(analysis, moves, gameInfo);
+
+    assert.match(summary, /Anna won with 91\.2% accuracy against —\./);
+    assert.doesNotMatch(summary, /opening/);
+  });
+
+  it('reports only the first blunder for each side', () => {
+    const summary = generateGameSummary(analysisWith({ 4: 'miss', 7: 'blunder', 8: 'blunder' }), moves, gameInfo);
+
+    assert.match(summary, /Anna blundered on move 3 \(3\. Bc4\)\./);
+    assert.doesNotMatch(summary, /move 5/);
+  });
+});
+
+describe('termination in the summary', () => {
+  it('says the loser ran out of time in a better position', () => {
+    const analysis = analysisWith({}, { resultConsistency: { isConsistent: false, finalEvaluation: 250 } });
+    const info: GameInfo = { ...gameInfo, result: '0-1', termination: 'Time forfeit' };
+
+    assert.match(generateGameSummary(analysis, moves, info), /Anna lost on time in a better position\.$/);
+  });
+
+  it('says the loser resigned in a losing position', () => {
+    const analysis = analysisWith({}, { resultConsistency: { isConsistent: true, finalEvaluation: 600 } });
+    const info: GameInfo = { ...gameInfo, termination: 'Anna won by resignation' };
+
+    assert.match(generateGameSummary(analysis, moves, info), /Boris resigned in a losing position\.$/);
+  });
+
+  it('adds nothing for a normal finish', () => {
+    const info: GameInfo = { ...gameInfo, termination: 'Normal' };
+
+    assert.match(generateGameSummary(analysisWith({}), moves, info), /against 64\.8%\.$/);
+  });
+});
@@ -0,0 +1,83 @@
+import { GameAnalysis } from '@/types/analysis';
+import { ChessMove, GameInfo } from '@/types/chess';
+import { formatAccuracy, toPlayerPerspective } from '@/utils/stockfish';
+
+export function generateGameSummary(
+  analysis: GameAnalysis,
+  moves: ChessMove[],
+  gameInfo: GameInfo
+): string {
+  const sentences: string[] = [];
+
+  // Opening, unless none of its moves could be scored
+  const openingAccuracy = analysis.phaseAnalysis.openingAccuracy;
+  if (openingAccuracy !== undefined) {
+    if (openingAccuracy >= 85) {
+      sentences.push(`The opening was played solidly (${openingAccuracy.toFixed(0)}%).`);
+    } else if (openingAccuracy >= 70) {
+      sentences.push(`The opening was played with some imprecision (${openingAccuracy.toFixed(0)}%).`);
+    } else {
+      sentences.push(`The opening went poorly (${openingAccuracy.toFixed(0)}%).`);
+    }
+  }
+
+  // First serious error for each side
+  (['w', 'b'] as const).forEach(color => {
+    const name = color === 'w' ? gameInfo.white : gameInfo.black;
+    const index = analysis.moves.findIndex((moveAnalysis, i) =>
+      moves[i]?.color === color &&
+      (moveAnalysis.classification === 'blunder' || moveAnalysis.classification === 'miss')
+    );
+
+    if (index === -1) {
+      sentences.push(`${name} made no blunders.`);
+    } else {
+      const move = moves[index];
+      const notation = `${move.moveNumber}${color === 'w' ? '.' : '...'} ${move.san}`;
+      sentences.push(`${name} blundered on move ${move.moveNumber} (${notation}).`);
+    }
+  });
+
+  if (analysis.endgameVerdict) {
+    sentences.push(`${analysis.endgameVerdict.description}.`);
+  }
+
+  // Result
+  const whiteAccuracy = formatAccuracy(analysis.whiteStats.accuracy);
+  const blackAccuracy = formatAccuracy(analysis.blackStats.accuracy);
+  if (gameInfo.result === '1-0') {
+    sentences.push(`${gameInfo.white} won with ${whiteAccuracy} accuracy against ${blackAccuracy}.`);
+  } else if (gameInfo.result === '0-1') {
+    sentences.push(`${gameInfo.black} won with ${blackAccuracy} accuracy against ${whiteAccuracy}.`);
+  } else if (gameInfo.result === '1/2-1/2') {
+    sentences.push(`The game was drawn, with accuracies of ${whiteAccuracy} and ${blackAccuracy}.`);
+  }
+
+  const terminationSentence = describeTermination(gameInfo, analysis.resultConsistency?.finalEvaluation ?? 0);
+  if (terminationSentence) {
+    sentences.push(terminationSentence);
+  }
+
+  return sentences.join(' ');
+}
+
+function describeTermination(gameInfo: GameInfo, finalEvaluation: number): string | null {
+  if (!gameInfo.termination || (gameInfo.result !== '1-0' && gameInfo.result !== '0-1')) {
+    return null;
+  }
+
+  const termination = gameInfo.termination.toLowerCase();
+  const loserIsWhite = gameInfo.result === '0-1';
+  const loser = loserIsWhite ? gameInfo.white : gameInfo.black;
+
+  // Final evaluation from the loser's point of view
+  const loserScore = toPlayerPerspective(finalEvaluation, loserIsWhite);
+  const position = Math.abs(loserScore) < 100
+    ? 'a roughly equal position'
+    : loserScore > 0 ? 'a better position' : 'a losing position';
+
+  if (termination.includes('resign')) return `${loser} resigned in ${position}.`;
+  if (termination.includes('time')) return `${loser} lost on time in ${position}.`;
+  if (termination.includes('abandon')) return `${loser} abandoned the game in ${position}.`;
+  return null;
+}